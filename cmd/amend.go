@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fixupPick is the --fixup value that picks the target commit from a list
+// of recent commits instead of naming it.
+const fixupPick = "pick"
+
+// recentCommitCount is the number of commits the --fixup picker offers.
+const recentCommitCount = 15
+
+// amendBase returns the revision the amended commit will be compared
+// against: the parent of HEAD, or the empty tree if HEAD is a root commit.
+func amendBase() (string, error) {
+	if err := execCommand("git", "rev-parse", "--verify", "--quiet", "HEAD").Run(); err != nil {
+		return "", fmt.Errorf("there is no commit to amend yet")
+	}
+	if err := execCommand("git", "rev-parse", "--verify", "--quiet", "HEAD^").Run(); err == nil {
+		return "HEAD^", nil
+	}
+	emptyTree := execCommand("git", "hash-object", "-t", "tree", "--stdin")
+	emptyTree.Stdin = strings.NewReader("")
+	tree, err := emptyTree.Output()
+	if err != nil {
+		return "", withExitCode(exitGitFailure, fmt.Errorf("could not compute the empty tree: %w", err))
+	}
+	return strings.TrimSpace(string(tree)), nil
+}
+
+// commitMessage returns the full message of rev.
+func commitMessage(rev string) (string, error) {
+	output, err := execCommand("git", "log", "-1", "--format=%B", rev, "--").Output()
+	if err != nil {
+		return "", withExitCode(exitGitFailure, fmt.Errorf("could not read the message of %s: %w", rev, err))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// checkNotPushed returns an error if rev is already on the current branch's
+// upstream, because rewriting it would rewrite published history, unless
+// --force is given. Without an upstream nothing is known to be pushed.
+func checkNotPushed(rev, action string) error {
+	if crForce {
+		return nil
+	}
+	upstream, err := execCommand("git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}").Output()
+	if err != nil {
+		return nil
+	}
+	if execCommand("git", "merge-base", "--is-ancestor", rev, "@{upstream}").Run() != nil {
+		return nil
+	}
+	return fmt.Errorf("refusing to %s %s: it has already been pushed to %s; use --force to do it anyway",
+		action, shortHash(rev), strings.TrimSpace(string(upstream)))
+}
+
+// shortHash abbreviates a full commit hash and leaves other revisions alone.
+func shortHash(rev string) string {
+	if len(rev) == 40 || len(rev) == 64 {
+		return rev[:7]
+	}
+	return rev
+}
+
+// resolveFixupTarget returns the commit --fixup rev targets, asking the user
+// to pick one from recent history if rev is "pick".
+func resolveFixupTarget(prompt *prompter, rev string) (loggedCommit, error) {
+	if rev == fixupPick {
+		return pickRecentCommit(prompt)
+	}
+	output, err := execCommand("git", "rev-parse", "--verify", "--quiet", rev+"^{commit}").Output()
+	if err != nil {
+		return loggedCommit{}, fmt.Errorf("--fixup: %q is not a commit", rev)
+	}
+	hash := strings.TrimSpace(string(output))
+	message, err := commitMessage(hash)
+	if err != nil {
+		return loggedCommit{}, err
+	}
+	return loggedCommit{hash: hash, message: message}, nil
+}
+
+// pickRecentCommit lists the most recent commits on the current branch,
+// marking those already pushed to the upstream, and asks which one to use.
+func pickRecentCommit(prompt *prompter) (loggedCommit, error) {
+	commits, err := logMessages("-n", strconv.Itoa(recentCommitCount), "HEAD")
+	if err != nil {
+		return loggedCommit{}, err
+	}
+	if len(commits) == 0 {
+		return loggedCommit{}, fmt.Errorf("there are no commits to fix up")
+	}
+
+	unpushed := map[string]bool{}
+	hasUpstream := execCommand("git", "rev-parse", "--verify", "--quiet", "@{upstream}").Run() == nil
+	if hasUpstream {
+		output, err := execCommand("git", "rev-list", "@{upstream}..HEAD").Output()
+		if err != nil {
+			return loggedCommit{}, withExitCode(exitGitFailure, fmt.Errorf("could not list unpushed commits: %w", err))
+		}
+		for _, hash := range strings.Fields(string(output)) {
+			unpushed[hash] = true
+		}
+	}
+
+	fmt.Println("\nRecent commits:")
+	for i, c := range commits {
+		note := ""
+		if hasUpstream && !unpushed[c.hash] {
+			note = " (pushed)"
+		}
+		subject, _, _ := strings.Cut(c.message, "\n")
+		fmt.Printf("  %2d. %s %s%s\n", i+1, shortHash(c.hash), subject, note)
+	}
+	for {
+		answer, err := prompt.ask(fmt.Sprintf("Fix up which commit? (1-%d, q to quit): ", len(commits)))
+		if err != nil {
+			return loggedCommit{}, err
+		}
+		if strings.EqualFold(answer, "q") || strings.EqualFold(answer, "quit") {
+			fmt.Println("Operation cancelled.")
+			return loggedCommit{}, withExitCode(exitCancelled, nil)
+		}
+		n, err := strconv.Atoi(answer)
+		if err == nil && n >= 1 && n <= len(commits) {
+			return commits[n-1], nil
+		}
+		fmt.Printf("Please enter a number between 1 and %d.\n", len(commits))
+	}
+}
+
+// commitFixup commits the staged changes as a "fixup!" commit for target,
+// to be squashed into it by "git rebase --autosquash".
+func commitFixup(target loggedCommit) error {
+	subject, _, _ := strings.Cut(target.message, "\n")
+	if crDryRun {
+		fmt.Printf("\nDry run: would commit the staged changes as \"fixup! %s\".\n", subject)
+		return nil
+	}
+	fmt.Println("Committing...")
+	if output, err := execCommand("git", "commit", "--fixup", target.hash).CombinedOutput(); err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("error committing: %w\n%s", err, strings.TrimSpace(string(output))))
+	}
+	fmt.Printf("Created \"fixup! %s\". Squash it with 'git rebase --autosquash -i %s~1'.\n", subject, shortHash(target.hash))
+	return nil
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+// setCrFlags sets the flags of cr used by the amend tests and restores them
+// when the test ends.
+func setCrFlags(t *testing.T, amend, yes bool, message string) {
+	oldAmend, oldYes, oldNoLLM, oldMessage := crAmend, crYes, crNoLLM, crMessage
+	t.Cleanup(func() { crAmend, crYes, crNoLLM, crMessage = oldAmend, oldYes, oldNoLLM, oldMessage })
+	crAmend, crYes, crNoLLM, crMessage = amend, yes, true, message
+}
+
+func TestAmend_MessageOnly(t *testing.T) {
+	inTestRepo(t)
+	writeFile(t, "main.go", "package main\n")
+	commitAll(t, "wip")
+	tree := git(t, "rev-parse", "HEAD^{tree}")
+	parent := git(t, "rev-parse", "HEAD^")
+	setCrFlags(t, true, true, "add the main package")
+
+	var err error
+	output := captureStdout(func() { err = runCr(nil) })
+	if err != nil {
+		t.Fatalf("cr --amend error: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "No new changes staged; rewording the last commit.") {
+		t.Errorf("cr --amend did not say it only rewords:\n%s", output)
+	}
+	if got := git(t, "log", "-1", "--format=%s"); !strings.Contains(got, "add the main package") {
+		t.Errorf("HEAD message = %q, want the new message", got)
+	}
+	if git(t, "rev-parse", "HEAD^{tree}") != tree || git(t, "rev-parse", "HEAD^") != parent {
+		t.Error("rewording changed the tree or parent of HEAD")
+	}
+}
+
+func TestAmend_RefusesPushedCommit(t *testing.T) {
+	repo := inTestRepo(t)
+	remote := t.TempDir()
+	git(t, "init", "-q", "--bare", remote)
+	git(t, "remote", "add", "origin", remote)
+	git(t, "push", "-q", "-u", "origin", "HEAD")
+	head := git(t, "rev-parse", "HEAD")
+	setCrFlags(t, true, true, "rewrite history")
+
+	var err error
+	captureStdout(func() { err = runCr(nil) })
+	if err == nil || !strings.Contains(err.Error(), "already been pushed") {
+		t.Errorf("cr --amend of a pushed commit in %s error = %v, want a refusal", repo, err)
+	}
+	if git(t, "rev-parse", "HEAD") != head {
+		t.Error("the pushed commit was amended")
+	}
+}
+
+func TestAmend_CancelLeavesHEAD(t *testing.T) {
+	inTestRepo(t)
+	writeFile(t, "main.go", "package main\n")
+	commitAll(t, "add main")
+	head := git(t, "rev-parse", "HEAD")
+	writeFile(t, "main.go", "package main\n\nfunc main() {}\n")
+	git(t, "add", "main.go")
+	setCrFlags(t, true, false, "add main")
+
+	oldIsTerminal := stdinIsTerminal
+	defer func() { stdinIsTerminal = oldIsTerminal }()
+	stdinIsTerminal = func() bool { return true }
+	defer simulateInput("q\nn\n")()
+
+	var err error
+	captureStdout(func() { err = runCr(nil) })
+	if code := exitCodeOf(err); code != exitCancelled {
+		t.Errorf("cancelled cr --amend exited with %d (%v), want %d", code, err, exitCancelled)
+	}
+	if git(t, "rev-parse", "HEAD") != head {
+		t.Error("a cancelled amend changed HEAD")
+	}
+	if git(t, "diff", "--cached", "--name-only") != "main.go" {
+		t.Error("a cancelled amend lost the staged change")
+	}
+}
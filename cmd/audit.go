@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/biswajitpain/gitter/internal/audit"
+	"github.com/biswajitpain/gitter/internal/config"
+	"github.com/biswajitpain/gitter/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditLimit     int
+	auditOlderThan string
+)
+
+// warnedAudit records that the user has been told the audit log could not
+// be written, so a command making several LLM requests warns only once.
+var warnedAudit bool
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect and prune the log of LLM requests",
+	Long: `Inspect the audit log of every request gitter sent to an LLM.
+
+The log is off by default. Turn it on with 'gitter config --audit-log'.
+Each entry records when and from which repository a request was made, the
+provider and model, a SHA-256 hash of the prompt as sent (after secrets
+were masked), the reply, the latency and the token usage if the provider
+reports it. With 'gitter config --audit-full-prompts' the prompts are
+recorded in full as well.
+
+The log is stored as JSON Lines in ` + "`~/.config/gitter/audit.jsonl`" + `.`,
+}
+
+var auditListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the most recent LLM requests",
+	Args:  cobra.NoArgs,
+	RunE:  handleAuditList,
+}
+
+var auditShowCmd = &cobra.Command{
+	Use:   "show [<id>]",
+	Short: "Show one LLM request and its reply (the latest by default)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  handleAuditShow,
+}
+
+var auditPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old entries from the audit log",
+	Example: `  gitter audit prune                    # drop entries older than 30 days
+  gitter audit prune --older-than 7d
+  gitter audit prune --older-than 0     # empty the log`,
+	Args: cobra.NoArgs,
+	RunE: handleAuditPrune,
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditListCmd, auditShowCmd, auditPruneCmd)
+
+	auditListCmd.Flags().IntVarP(&auditLimit, "limit", "n", 20, "Number of entries to list (0 lists all)")
+	auditPruneCmd.Flags().StringVar(&auditOlderThan, "older-than", "30d", "Remove entries older than this age, in days (\"30d\") or as a Go duration (\"12h\")")
+}
+
+// newAuditingClient wraps client so that its requests are recorded in the
+// audit log, if the log is turned on.
+func newAuditingClient(cfg config.Config, client llm.LLMClient) (llm.LLMClient, error) {
+	if !cfg.AuditLog {
+		return client, nil
+	}
+	path, err := audit.Path()
+	if err != nil {
+		return nil, fmt.Errorf("could not locate the audit log: %w", err)
+	}
+	repo := ""
+	if top, err := execCommand("git", "rev-parse", "--show-toplevel").Output(); err == nil {
+		repo = strings.TrimSpace(string(top))
+	}
+	return &llm.AuditingClient{
+		Client:      client,
+		Path:        path,
+		Repo:        repo,
+		Provider:    cfg.Provider,
+		Model:       llm.ModelName(cfg),
+		FullPrompts: cfg.AuditFullPrompts,
+		OnError:     warnAuditFailed,
+	}, nil
+}
+
+// warnAuditFailed tells the user that a request could not be recorded.
+func warnAuditFailed(err error) {
+	if warnedAudit {
+		return
+	}
+	warnedAudit = true
+	fmt.Fprintf(os.Stderr, "Warning: could not record the LLM request in the audit log: %v\n", err)
+}
+
+// readAuditLog returns the entries of the audit log, warning about lines
+// that could not be parsed.
+func readAuditLog() ([]audit.Entry, error) {
+	path, err := audit.Path()
+	if err != nil {
+		return nil, err
+	}
+	entries, bad, err := audit.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if bad > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: skipped %d unreadable line(s) in %s\n", bad, path)
+	}
+	return entries, nil
+}
+
+func handleAuditList(cmd *cobra.Command, args []string) error {
+	entries, err := readAuditLog()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("The audit log is empty.")
+		if cfg, err := config.LoadConfig(); err == nil && !cfg.AuditLog {
+			fmt.Println("It is off; turn it on with 'gitter config --audit-log'.")
+		}
+		return nil
+	}
+	if auditLimit > 0 && len(entries) > auditLimit {
+		entries = entries[len(entries)-auditLimit:]
+	}
+
+	fmt.Printf("%-12s  %-19s  %-16s  %-28s  %-14s  %-11s  %8s  %s\n", "ID", "TIME", "REPOSITORY", "MODEL", "KIND", "TOKENS", "LATENCY", "STATUS")
+	for _, e := range entries {
+		status := "ok"
+		if e.Error != "" {
+			status = "error"
+		}
+		fmt.Printf("%-12s  %-19s  %-16s  %-28s  %-14s  %-11s  %8s  %s\n",
+			e.ID,
+			e.Time.Local().Format("2006-01-02 15:04:05"),
+			truncate(repoName(e.Repo), 16),
+			truncate(e.Provider+"/"+e.Model, 28),
+			e.Kind,
+			tokenCounts(e),
+			formatLatency(e.LatencyMS),
+			status)
+	}
+	return nil
+}
+
+func handleAuditShow(cmd *cobra.Command, args []string) error {
+	entries, err := readAuditLog()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return errors.New("the audit log is empty")
+	}
+	entry := entries[len(entries)-1]
+	if len(args) == 1 {
+		if entry, err = audit.Find(entries, args[0]); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("ID:         %s\n", entry.ID)
+	fmt.Printf("Time:       %s\n", entry.Time.Local().Format(time.RFC3339))
+	if entry.Repo != "" {
+		fmt.Printf("Repository: %s\n", entry.Repo)
+	}
+	fmt.Printf("Provider:   %s\n", entry.Provider)
+	fmt.Printf("Model:      %s\n", entry.Model)
+	fmt.Printf("Kind:       %s\n", entry.Kind)
+	fmt.Printf("Latency:    %s\n", formatLatency(entry.LatencyMS))
+	if tokens := tokenCounts(entry); tokens != "-" {
+		fmt.Printf("Tokens:     %s (input/output)\n", tokens)
+	} else {
+		fmt.Println("Tokens:     not reported")
+	}
+	fmt.Printf("Prompt:     sha256:%s, %d bytes\n", entry.PromptSHA256, entry.PromptBytes)
+	if entry.Error != "" {
+		fmt.Printf("Error:      %s\n", entry.Error)
+	}
+
+	if entry.Prompt != "" {
+		fmt.Printf("\n--- System prompt ---\n%s\n", entry.System)
+		fmt.Printf("\n--- Prompt ---\n%s\n", entry.Prompt)
+	} else {
+		fmt.Println("\nOnly the prompt's hash was recorded; use 'gitter config --audit-full-prompts' to record prompts in full.")
+	}
+	if entry.Response != "" {
+		fmt.Printf("\n--- Response ---\n%s\n", entry.Response)
+	}
+	return nil
+}
+
+func handleAuditPrune(cmd *cobra.Command, args []string) error {
+	age, err := parseAge(auditOlderThan)
+	if err != nil {
+		return err
+	}
+	path, err := audit.Path()
+	if err != nil {
+		return err
+	}
+	removed, err := audit.Prune(path, timeNow().Add(-age))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %d entries from the audit log.\n", removed)
+	return nil
+}
+
+// parseAge parses an age given in days, such as "30d", or as a Go
+// duration, such as "12h".
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q, expected e.g. \"30d\" or \"12h\"", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q, expected e.g. \"30d\" or \"12h\"", s)
+	}
+	return d, nil
+}
+
+// repoName shortens a repository path to its directory name.
+func repoName(path string) string {
+	if path == "" {
+		return "-"
+	}
+	return filepath.Base(path)
+}
+
+// truncate shortens s to at most n bytes, marking the cut with "~".
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-1] + "~"
+}
+
+// tokenCounts renders an entry's token usage as "input/output", or "-" if
+// the provider did not report it.
+func tokenCounts(e audit.Entry) string {
+	if e.InputTokens == 0 && e.OutputTokens == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d", e.InputTokens, e.OutputTokens)
+}
+
+// formatLatency renders a latency in milliseconds in seconds.
+func formatLatency(ms int64) string {
+	return fmt.Sprintf("%.1fs", float64(ms)/1000)
+}
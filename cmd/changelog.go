@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/biswajitpain/gitter/internal/changelog"
+	"github.com/biswajitpain/gitter/internal/config"
+	"github.com/biswajitpain/gitter/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+var (
+	changelogFormat  string
+	changelogVersion string
+	changelogPolish  bool
+)
+
+// changelogCmd represents the changelog command
+var changelogCmd = &cobra.Command{
+	Use:   "changelog [<from>..<to> | <from> [<to>]]",
+	Short: "Generate a changelog from conventional commit history",
+	Long: `Generate a changelog for the commits between two refs or tags. Commits are
+parsed as Conventional Commits and grouped by type (Features, Bug Fixes,
+...), sorted by scope, with breaking changes listed first. Commits that
+are not conventional are listed under "Other Changes". Hashes link to the
+commit on the web when the origin remote is hosted on GitHub, GitLab or
+similar.
+
+Without arguments, the changelog covers the commits since the latest tag.
+When HEAD is tagged, it covers the commits that tag released instead.
+
+Formats:
+  markdown        conventional-changelog style (default)
+  keepachangelog  https://keepachangelog.com sections
+  json            machine-readable entries
+
+With --polish the configured LLM rewrites each entry into user-facing
+language; the original entries are kept if that fails.`,
+	Example: `  gitter changelog
+  gitter changelog v1.1.0..v1.2.0
+  gitter changelog v1.1.0 --version v1.2.0 --format keepachangelog
+  gitter changelog --format json`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: handleChangelogCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(changelogCmd)
+
+	changelogCmd.Flags().StringVarP(&changelogFormat, "format", "f", "markdown", "Output format: markdown, keepachangelog or json")
+	changelogCmd.Flags().StringVar(&changelogVersion, "version", "", "Version heading (default: the tag at <to>, or Unreleased)")
+	changelogCmd.Flags().BoolVar(&changelogPolish, "polish", false, "Rewrite entries into user-facing language with the configured LLM")
+}
+
+func handleChangelogCommand(cmd *cobra.Command, args []string) error {
+	switch changelogFormat {
+	case "markdown", "keepachangelog", "json":
+	default:
+		return fmt.Errorf("unknown format %q, use markdown, keepachangelog or json", changelogFormat)
+	}
+
+	from, to, err := changelogRange(args)
+	if err != nil {
+		return err
+	}
+
+	log, err := buildChangelog(from, to, changelogVersion)
+	if err != nil {
+		return err
+	}
+	if log.Empty() {
+		fmt.Fprintln(os.Stderr, "No commits in range.")
+	}
+	if changelogPolish && !log.Empty() {
+		polishChangelog(log)
+	}
+
+	switch changelogFormat {
+	case "json":
+		data, err := log.JSON()
+		if err != nil {
+			return fmt.Errorf("could not encode changelog: %w", err)
+		}
+		os.Stdout.Write(data)
+	case "keepachangelog":
+		fmt.Print(log.KeepAChangelog())
+	default:
+		fmt.Print(log.Markdown())
+	}
+	return nil
+}
+
+// changelogRange returns the refs the changelog runs between, given as
+// "<from>..<to>", "<from> [<to>]" or nothing, and checks that both exist.
+// Without arguments from is the latest tag before to, or empty when there
+// is no tag to start from.
+func changelogRange(args []string) (from, to string, err error) {
+	to = "HEAD"
+	switch {
+	case len(args) == 1 && strings.Contains(args[0], "..."):
+		return "", "", fmt.Errorf("%q is a symmetric difference, use <from>..<to>", args[0])
+	case len(args) == 1 && strings.Contains(args[0], ".."):
+		from, to, _ = strings.Cut(args[0], "..")
+		if from == "" {
+			return "", "", fmt.Errorf("%q has no start, use <from>..<to>", args[0])
+		}
+		if to == "" {
+			to = "HEAD"
+		}
+	case len(args) >= 1:
+		from = args[0]
+		if len(args) == 2 {
+			to = args[1]
+		}
+	default:
+		rev := to
+		if execCommand("git", "describe", "--tags", "--exact-match", to).Run() == nil {
+			// to is tagged itself: show what that tag released rather than
+			// the empty range from the tag to itself.
+			rev = to + "^"
+		}
+		from = latestTag(rev)
+	}
+
+	for _, ref := range []string{from, to} {
+		if ref == "" {
+			continue
+		}
+		if err := execCommand("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}").Run(); err != nil {
+			return "", "", fmt.Errorf("%q is not a commit, tag or branch", ref)
+		}
+	}
+	return from, to, nil
+}
+
+// latestTag returns the most recent tag reachable from rev, or an empty
+// string if there is none.
+func latestTag(rev string) string {
+	output, err := execCommand("git", "describe", "--tags", "--abbrev=0", rev).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// buildChangelog collects the commits in from..to (all of to's history
+// when from is empty) into a changelog. When version is empty the heading
+// is the tag at to, or "Unreleased".
+func buildChangelog(from, to, version string) (*changelog.Changelog, error) {
+	revRange := to
+	if from != "" {
+		revRange = from + ".." + to
+	}
+	commits, err := logMessages(revRange)
+	if err != nil {
+		return nil, withExitCode(exitGitFailure, err)
+	}
+
+	opts := changelog.Options{Version: version}
+	if opts.Version == "" {
+		opts.Version = "Unreleased"
+		if tag, err := execCommand("git", "describe", "--tags", "--exact-match", to).Output(); err == nil {
+			opts.Version = strings.TrimSpace(string(tag))
+			if date, err := execCommand("git", "log", "-1", "--format=%cs", to).Output(); err == nil {
+				opts.Date = strings.TrimSpace(string(date))
+			}
+		}
+	} else {
+		opts.Date = timeNow().Format("2006-01-02")
+	}
+	if remote, err := execCommand("git", "remote", "get-url", "origin").Output(); err == nil {
+		opts.CommitURL = changelog.CommitURL(string(remote))
+	}
+
+	input := make([]changelog.Commit, len(commits))
+	for i, commit := range commits {
+		input[i] = changelog.Commit{Hash: commit.hash, Message: commit.message}
+	}
+	return changelog.Build(input, opts), nil
+}
+
+// polishChangelog rewrites the changelog's entries with the configured
+// LLM. On any failure the user is warned and the entries are left as is.
+func polishChangelog(log *changelog.Changelog) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load config: %v\n", err)
+	}
+	llmClient, err := newLLMClientFunc(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot polish changelog: %v\n", err)
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	texts := log.Texts()
+	entries := make([]string, len(texts))
+	for i, text := range texts {
+		entries[i] = *text
+	}
+
+	fmt.Fprintf(os.Stderr, "Polishing %d changelog entries with %s (press Ctrl-C to cancel)...\n", len(entries), cfg.Provider)
+	polished, err := llm.PolishChangelogEntries(ctx, llmClient, entries)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		fmt.Fprintln(os.Stderr, "LLM request cancelled, keeping the original entries.")
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not polish changelog, keeping the original entries: %v\n", err)
+		return
+	}
+	for i, text := range texts {
+		*text = polished[i]
+	}
+}
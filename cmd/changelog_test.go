@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChangelogRange(t *testing.T) {
+	inTestRepo(t)
+	git(t, "tag", "v1.0")
+	git(t, "commit", "-q", "--allow-empty", "-m", "feat: more")
+	git(t, "tag", "v2.0")
+
+	tests := []struct {
+		args     []string
+		from, to string
+		err      string
+	}{
+		{args: nil, from: "v1.0", to: "HEAD"},
+		{args: []string{"v1.0..v2.0"}, from: "v1.0", to: "v2.0"},
+		{args: []string{"v1.0.."}, from: "v1.0", to: "HEAD"},
+		{args: []string{"v1.0", "v2.0"}, from: "v1.0", to: "v2.0"},
+		{args: []string{"v1.0...v2.0"}, err: "symmetric difference"},
+		{args: []string{"..v2.0"}, err: "has no start"},
+		{args: []string{"v1.0..v3.0"}, err: `"v3.0" is not a commit`},
+		{args: []string{"v0.9"}, err: `"v0.9" is not a commit`},
+	}
+	for _, tt := range tests {
+		from, to, err := changelogRange(tt.args)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("changelogRange(%q) error = %v, want %q", tt.args, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || from != tt.from || to != tt.to {
+			t.Errorf("changelogRange(%q) = %q, %q, %v, want %q, %q", tt.args, from, to, err, tt.from, tt.to)
+		}
+	}
+
+	git(t, "commit", "-q", "--allow-empty", "-m", "fix: after the release")
+	if from, _, err := changelogRange(nil); err != nil || from != "v2.0" {
+		t.Errorf("changelogRange(nil) on an untagged HEAD = %q, %v, want %q", from, err, "v2.0")
+	}
+}
@@ -1,16 +1,38 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"github.com/biswajitpain/gitter/internal/config"
-
+	"github.com/biswajitpain/gitter/internal/keyring"
+	"github.com/biswajitpain/gitter/internal/pathpolicy"
+	"github.com/biswajitpain/gitter/internal/redact"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	provider string
-	apiKey   string
+	provider     string
+	apiKey       string
+	model        string
+	baseURL      string
+	keepAlive    string
+	headers      []string
+	apiVersion   string
+	maxTokens    int
+	standupRepos []string
+	redactRules  []string
+	llmInclude   []string
+	llmExclude   []string
+	auditLog     bool
+	auditFull    bool
+	showOrigin   bool
+	keyCommand   string
+	plaintext    bool
 )
 
 // configCmd represents the config command
@@ -18,26 +40,128 @@ var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Configure the LLM provider and API key",
 	Long: `Configure the settings for the LLM provider used for generating commit messages.
-Currently supports OpenAI.
+Supported providers: openai, anthropic, ollama, llamacpp, openai-compatible, azure.
+
+The ollama and llamacpp providers talk to a local server and need no API key,
+so they work on machines without network access.
+
+The openai-compatible provider works with any server implementing the OpenAI
+chat completions API (vLLM, LiteLLM, ...) and requires --base-url. The azure
+provider takes the deployment URL as --base-url and sends --api-version.
 
-Example:
-gitter config --provider openai --api-key sk-...`,
+The API key is kept in the system keyring when there is one (secret-tool on
+Linux, the keychain on macOS), otherwise or with --plaintext in the config
+file. --api-key-command reads it from a password manager instead.
+
+Examples:
+gitter config --provider openai --api-key sk-...
+gitter config --provider openai --api-key-command "pass show openai"
+gitter config --provider anthropic --api-key sk-ant-... --model claude-3-5-sonnet-latest
+gitter config --provider ollama --model qwen2.5-coder --keep-alive 10m
+gitter config --provider llamacpp --base-url http://localhost:8080/v1
+gitter config --provider openai-compatible --base-url http://litellm.internal/v1 --model gpt-4o --header "X-Team: infra"
+gitter config --provider azure --base-url https://myres.openai.azure.com/openai/deployments/gpt-4o --api-version 2024-06-01`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if provider == "" && apiKey == "" {
+		if cmd.Flags().NFlag() == 0 {
 			cmd.Help()
 			return nil // Showing help is not an error, return nil
 		}
+		if showOrigin {
+			if cmd.Flags().NFlag() > 1 {
+				return errors.New("--show-origin cannot be combined with other options")
+			}
+			return printOrigins()
+		}
 
-		cfg, err := config.LoadConfig()
+		// Only the global file is edited; overrides from the repository and
+		// the environment must not end up in it.
+		cfg, err := config.LoadGlobalConfig()
 		if err != nil {
 			return fmt.Errorf("error loading existing config: %w", err)
 		}
 
+		previousProvider := cfg.Provider
 		if provider != "" {
 			cfg.Provider = provider
 		}
+		if apiKey != "" && cmd.Flags().Changed("api-key-command") {
+			return errors.New("--api-key cannot be combined with --api-key-command")
+		}
+		if plaintext && apiKey == "" {
+			return errors.New("--plaintext only applies to --api-key")
+		}
 		if apiKey != "" {
-			cfg.APIKey = apiKey
+			if err := storeAPIKey(&cfg, apiKey); err != nil {
+				return err
+			}
+		}
+		if cmd.Flags().Changed("api-key-command") {
+			cfg.APIKeyCommand = keyCommand
+			if keyCommand != "" {
+				// A key stored before would take precedence over the command.
+				cfg.APIKey, cfg.APIKeyStore = "", ""
+			}
+		}
+		if apiKey == "" {
+			switchKeyringProvider(&cfg, previousProvider)
+		}
+		if model != "" {
+			cfg.Model = model
+		}
+		if baseURL != "" {
+			cfg.BaseURL = baseURL
+		}
+		if keepAlive != "" {
+			cfg.KeepAlive = keepAlive
+		}
+		if apiVersion != "" {
+			cfg.APIVersion = apiVersion
+		}
+		if cmd.Flags().Changed("max-diff-tokens") {
+			cfg.MaxDiffTokens = maxTokens
+		}
+		if cmd.Flags().Changed("standup-repos") {
+			cfg.StandupRepos = standupRepos
+		}
+		if cmd.Flags().Changed("redact-pattern") {
+			cfg.RedactPatterns = nil
+			for _, pattern := range redactRules {
+				if pattern != "" {
+					cfg.RedactPatterns = append(cfg.RedactPatterns, pattern)
+				}
+			}
+			if _, err := redact.New(cfg.RedactPatterns); err != nil {
+				return err
+			}
+		}
+		if cmd.Flags().Changed("llm-include") {
+			cfg.LLMInclude = llmInclude
+		}
+		if cmd.Flags().Changed("llm-exclude") {
+			cfg.LLMExclude = llmExclude
+		}
+		if cmd.Flags().Changed("audit-log") {
+			cfg.AuditLog = auditLog
+		}
+		if cmd.Flags().Changed("audit-full-prompts") {
+			cfg.AuditFullPrompts = auditFull
+		}
+		if _, err := pathpolicy.New(nil, cfg.LLMInclude, cfg.LLMExclude); err != nil {
+			return err
+		}
+		for _, header := range headers {
+			name, value, err := parseHeader(header)
+			if err != nil {
+				return err
+			}
+			if value == "" {
+				delete(cfg.Headers, name)
+				continue
+			}
+			if cfg.Headers == nil {
+				cfg.Headers = map[string]string{}
+			}
+			cfg.Headers[name] = value
 		}
 
 		if err := config.SaveConfig(cfg); err != nil {
@@ -48,16 +172,173 @@ gitter config --provider openai --api-key sk-...`,
 		if cfg.Provider != "" {
 			fmt.Printf("Provider: %s\n", cfg.Provider)
 		}
-		if cfg.APIKey != "" {
-			fmt.Println("API Key: [set]")
+		if cfg.Model != "" {
+			fmt.Printf("Model: %s\n", cfg.Model)
+		}
+		if cfg.BaseURL != "" {
+			fmt.Printf("Base URL: %s\n", cfg.BaseURL)
+		}
+		if cfg.KeepAlive != "" {
+			fmt.Printf("Keep-alive: %s\n", cfg.KeepAlive)
+		}
+		if cfg.APIVersion != "" {
+			fmt.Printf("API version: %s\n", cfg.APIVersion)
+		}
+		if cfg.MaxDiffTokens != 0 {
+			fmt.Printf("Max diff tokens: %d\n", cfg.MaxDiffTokens)
+		}
+		if len(cfg.StandupRepos) > 0 {
+			fmt.Printf("Standup repositories: %s\n", strings.Join(cfg.StandupRepos, ", "))
+		}
+		if len(cfg.RedactPatterns) > 0 {
+			fmt.Printf("Extra redaction patterns: %d\n", len(cfg.RedactPatterns))
+		}
+		if len(cfg.LLMInclude) > 0 {
+			fmt.Printf("Files sent to the LLM: %s\n", strings.Join(cfg.LLMInclude, ", "))
+		}
+		if len(cfg.LLMExclude) > 0 {
+			fmt.Printf("Files withheld from the LLM: %s\n", strings.Join(cfg.LLMExclude, ", "))
+		}
+		if cfg.AuditLog {
+			if cfg.AuditFullPrompts {
+				fmt.Println("Audit log: on (full prompts)")
+			} else {
+				fmt.Println("Audit log: on (prompt hashes)")
+			}
+		}
+		if len(cfg.Headers) > 0 {
+			// Header values often carry credentials, so only the names are shown.
+			names := make([]string, 0, len(cfg.Headers))
+			for name := range cfg.Headers {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fmt.Printf("Extra headers: %s\n", strings.Join(names, ", "))
+		}
+		switch {
+		case cfg.APIKey != "":
+			fmt.Println("API Key: [set] (in the config file)")
+		case cfg.APIKeyStore == config.KeyringStore:
+			fmt.Println("API Key: [set] (in the system keyring)")
+		case cfg.APIKeyCommand != "":
+			fmt.Printf("API key command: %s\n", cfg.APIKeyCommand)
 		}
 		return nil // Success, return nil
 	},
 }
 
+// storeAPIKey stores key in the system keyring, or in the config file if
+// there is no keyring or --plaintext is given.
+func storeAPIKey(cfg *config.Config, key string) error {
+	cfg.APIKeyCommand = ""
+	if !plaintext {
+		ring, err := keyring.Default()
+		if err == nil {
+			if cfg.Provider == "" {
+				return errors.New("set a provider with --provider to store its API key in the keyring")
+			}
+			if err := ring.Set(config.KeyringService, cfg.Provider, key); err != nil {
+				return fmt.Errorf("%w; use --plaintext to store the key in the config file instead", err)
+			}
+			cfg.APIKey, cfg.APIKeyStore = "", config.KeyringStore
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v; the API key is stored in plaintext in the config file.\n", err)
+	}
+	cfg.APIKey, cfg.APIKeyStore = key, ""
+	return nil
+}
+
+// switchKeyringProvider follows a provider change when the API key is kept
+// in the keyring, where it is stored under the provider's name. The new
+// provider's key is used if the keyring has one; otherwise the config stops
+// pointing at the keyring, so that loading it does not fail.
+func switchKeyringProvider(cfg *config.Config, previous string) {
+	if cfg.APIKeyStore != config.KeyringStore || cfg.Provider == previous {
+		return
+	}
+	if ring, err := keyring.Default(); err == nil {
+		if _, err := ring.Get(config.KeyringService, cfg.Provider); err == nil {
+			return
+		}
+	}
+	cfg.APIKeyStore = ""
+	fmt.Printf("The system keyring has no %s API key; set one with --api-key if it needs one. The %s key is kept there.\n", cfg.Provider, previous)
+}
+
+// printOrigins prints each effective setting and where it came from.
+// Secrets are not shown, and the API key is not read with api_key_command
+// or from the keyring just to show that it is set.
+func printOrigins() error {
+	effective, err := config.LoadEffectiveUnresolved()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	for _, key := range config.Keys() {
+		value := formatSetting(key, config.Value(effective.Config, key))
+		fmt.Printf("%-20s %-30s %s\n", key, value, effective.Origin(key))
+	}
+	return nil
+}
+
+// formatSetting renders a setting's value for display. The API key is only
+// shown as set, and of the headers only the names, since their values
+// often carry credentials.
+func formatSetting(key string, value any) string {
+	switch v := value.(type) {
+	case string:
+		if key == "api_key" && v != "" {
+			return "[set]"
+		}
+		return v
+	case []string:
+		return strings.Join(v, ",")
+	case map[string]string:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return strings.Join(names, ",")
+	case int:
+		if v == 0 {
+			return ""
+		}
+		return strconv.Itoa(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// parseHeader splits a "Name: value" flag into its name and value.
+// An empty value means the header should be removed.
+func parseHeader(header string) (string, string, error) {
+	name, value, ok := strings.Cut(header, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid header %q, expected \"Name: value\"", header)
+	}
+	return name, strings.TrimSpace(value), nil
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
 
-	configCmd.Flags().StringVarP(&provider, "provider", "p", "", "The LLM provider (e.g., 'openai')")
-	configCmd.Flags().StringVarP(&apiKey, "api-key", "k", "", "The API key for the LLM provider")
+	configCmd.Flags().StringVarP(&provider, "provider", "p", "", "The LLM provider ('openai', 'anthropic', 'ollama', 'llamacpp', 'openai-compatible' or 'azure')")
+	configCmd.Flags().StringVarP(&apiKey, "api-key", "k", "", "The API key for the LLM provider, stored in the system keyring when there is one")
+	configCmd.Flags().BoolVar(&plaintext, "plaintext", false, "Store --api-key in the config file even if a keyring is available")
+	configCmd.Flags().StringVar(&keyCommand, "api-key-command", "", "A shell command printing the API key, e.g. 'pass show openai' (empty clears it)")
+	configCmd.Flags().StringVarP(&model, "model", "m", "", "The model to use (defaults to the provider's default)")
+	configCmd.Flags().StringVar(&baseURL, "base-url", "", "The provider endpoint (e.g., 'http://localhost:11434' for Ollama or an Azure deployment URL)")
+	configCmd.Flags().StringArrayVar(&headers, "header", nil, "An extra HTTP header as \"Name: value\" (repeatable; an empty value removes it)")
+	configCmd.Flags().StringVar(&apiVersion, "api-version", "", "The api-version query parameter (required by Azure OpenAI)")
+	configCmd.Flags().IntVar(&maxTokens, "max-diff-tokens", 0, "Approximate token budget for diffs sent to the LLM (0 uses the default)")
+	configCmd.Flags().StringSliceVar(&standupRepos, "standup-repos", nil, "Extra repositories 'gitter standup' reports on (comma-separated; empty clears the list)")
+	configCmd.Flags().StringArrayVar(&redactRules, "redact-pattern", nil, "A regular expression for secrets to mask before diffs are sent to the LLM (repeatable; replaces the list, an empty value clears it)")
+	configCmd.Flags().StringSliceVar(&llmInclude, "llm-include", nil, "Only send the diffs of files matching these globs to the LLM (comma-separated, gitignore syntax; empty clears the list)")
+	configCmd.Flags().StringSliceVar(&llmExclude, "llm-exclude", nil, "Never send the diffs of files matching these globs to the LLM (comma-separated, gitignore syntax; empty clears the list)")
+	configCmd.Flags().BoolVar(&auditLog, "audit-log", false, "Record every LLM request in the audit log (see 'gitter audit'; --audit-log=false turns it off)")
+	configCmd.Flags().BoolVar(&auditFull, "audit-full-prompts", false, "Record the prompts sent to the LLM in full instead of only their hash")
+	configCmd.Flags().BoolVar(&showOrigin, "show-origin", false, "Show each effective setting and where it came from, then exit")
+	configCmd.Flags().StringVar(&keepAlive, "keep-alive", "", "How long Ollama keeps the model loaded (e.g., '5m', '-1')")
 }
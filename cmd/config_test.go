@@ -1 +1,121 @@
 package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/biswajitpain/gitter/internal/config"
+	"github.com/biswajitpain/gitter/internal/keyring"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantName  string
+		wantValue string
+		wantErr   bool
+	}{
+		{input: "X-Team: infra", wantName: "X-Team", wantValue: "infra"},
+		{input: "Authorization:Bearer a:b", wantName: "Authorization", wantValue: "Bearer a:b"},
+		{input: "X-Remove:", wantName: "X-Remove", wantValue: ""},
+		{input: "no-colon", wantErr: true},
+		{input: ": value", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		name, value, err := parseHeader(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseHeader(%q) expected an error, got none", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseHeader(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if name != tt.wantName || value != tt.wantValue {
+			t.Errorf("parseHeader(%q) = (%q, %q), want (%q, %q)", tt.input, name, value, tt.wantName, tt.wantValue)
+		}
+	}
+}
+
+func TestFormatSetting(t *testing.T) {
+	tests := []struct {
+		key   string
+		value any
+		want  string
+	}{
+		{"api_key", "sk-secret", "[set]"},
+		{"api_key", "", ""},
+		{"model", "gpt-4o", "gpt-4o"},
+		{"headers", map[string]string{"X-Team": "infra", "Authorization": "Bearer x"}, "Authorization,X-Team"},
+		{"llm_exclude", []string{"nda/", "*.pem"}, "nda/,*.pem"},
+		{"max_diff_tokens", 0, ""},
+		{"max_diff_tokens", 4000, "4000"},
+		{"audit_log", true, "true"},
+	}
+	for _, tt := range tests {
+		if got := formatSetting(tt.key, tt.value); got != tt.want {
+			t.Errorf("formatSetting(%s, %v) = %q, want %q", tt.key, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSwitchKeyringProvider(t *testing.T) {
+	inTestRepo(t)
+	ringPath := filepath.Join(t.TempDir(), "keyring.json")
+	t.Setenv(keyring.FileEnv, ringPath)
+	ring := &keyring.FileKeyring{Path: ringPath}
+	if err := ring.Set(config.KeyringService, "openai", "sk-openai"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ring.Set(config.KeyringService, "anthropic", "sk-anthropic"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The keyring has a key for the new provider, so it is used.
+	cfg := config.Config{Provider: "anthropic", APIKeyStore: config.KeyringStore}
+	captureStdout(func() { switchKeyringProvider(&cfg, "openai") })
+	if cfg.APIKeyStore != config.KeyringStore {
+		t.Errorf("APIKeyStore = %q, want the keyring to stay in use", cfg.APIKeyStore)
+	}
+
+	// It has none for ollama, so the config must stop pointing at it.
+	cfg = config.Config{Provider: "ollama", APIKeyStore: config.KeyringStore}
+	output := captureStdout(func() { switchKeyringProvider(&cfg, "openai") })
+	if cfg.APIKeyStore != "" || !strings.Contains(output, "no ollama API key") {
+		t.Errorf("APIKeyStore = %q, output %q, want the keyring dropped and a note", cfg.APIKeyStore, output)
+	}
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.LoadConfig(); err != nil {
+		t.Errorf("LoadConfig() after switching provider: %v", err)
+	}
+	if _, err := ring.Get(config.KeyringService, "openai"); err != nil {
+		t.Errorf("the openai key should stay in the keyring: %v", err)
+	}
+}
+
+func TestPrintOriginsRunsNoHelper(t *testing.T) {
+	dir := inTestRepo(t)
+	marker := filepath.Join(dir, "helper-ran")
+	if err := config.SaveConfig(config.Config{Provider: "openai", APIKeyCommand: "touch " + marker + "; echo sk-from-helper"}); err != nil {
+		t.Fatal(err)
+	}
+
+	output := captureStdout(func() {
+		if err := printOrigins(); err != nil {
+			t.Errorf("printOrigins() error: %v", err)
+		}
+	})
+	if !strings.Contains(output, "api_key_command") {
+		t.Errorf("printOrigins() output should list api_key_command:\n%s", output)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("printOrigins() ran api_key_command")
+	}
+}
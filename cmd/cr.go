@@ -1,283 +1,450 @@
 package cmd
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/biswajitpain/gitter/internal/config"
-	"github.com/biswajitpain/gitter/internal/llm"
+	"github.com/biswajitpain/gitter/internal/conventional"
+	"github.com/biswajitpain/gitter/internal/diff"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
 )
 
-// crCmd represents the cr command
+// Flags for the cr command.
+var (
+	crYes          bool
+	crAll          bool
+	crTrackedOnly  bool
+	crMessage      string
+	crNoLLM        bool
+	crDryRun       bool
+	crSplit        bool
+	crSplitBy      string
+	crInteractive  bool
+	crAmend        bool
+	crFixup        string
+	crForce        bool
+	crAllowSecrets bool
+)
 
+// crCmd represents the cr command
 var crCmd = &cobra.Command{
-
-	Use:   "cr",
-
+	Use:   "cr [<pathspec>...]",
 	Short: "Create a commit with an AI-generated message",
-
 	Long: `The 'cr' command automates the commit process.
 
-It stages files, generates a diff, and uses an LLM (if configured) 
-
-to create a conventional commit message.`,
-
+It stages files, generates a diff, and uses an LLM (if configured)
+to create a conventional commit message. Before committing you can
+accept the message, edit it in your editor, regenerate it with an
+extra hint, switch to the template message, or abort.
+
+Pathspecs, --all and --tracked-only say what to stage first: tracked
+files matching the pathspecs (or all tracked files) are staged, and
+untracked ones are listed and only staged once you confirm them, or
+straight away with --all. --tracked-only never stages untracked files.
+If nothing is staged and none of these is given, cr asks what to do.
+
+With --interactive, or by answering "s" when nothing is staged, you
+first pick the files to stage from a list and can then go through their
+hunks one by one, like 'git add -p'.
+
+For scripts and CI, combine --yes, --all and --message so that no
+question needs an answer. When stdin is not a terminal and a question
+would be asked, cr fails immediately instead of waiting.
+
+With --amend, the last commit is rewritten: its diff and any newly staged
+changes are described together, starting from its current message.
+--fixup <rev> commits the staged changes as "fixup! <subject>" for
+'git rebase --autosquash'; use --fixup pick to choose the commit from
+recent history. Both refuse to touch commits that are already on the
+upstream branch unless --force is given.
+
+With --split, the staged changes are divided into several commits: by
+directory, by kind of file (tests, docs, build files, source by
+extension) or into logical groups of hunks chosen by the LLM. The plan is
+shown for approval, then each group is committed with its own message.
+If anything fails midway the original index is restored.
+
+Likely secrets such as cloud keys, tokens and private keys are masked
+before anything is sent to the LLM, and cr refuses to commit changes
+that add them unless --allow-secrets is given.
+
+Exit codes:
+  0  committed (or printed the message with --dry-run)
+  1  error, including a question that could not be asked
+  2  nothing to commit
+  3  cancelled
+  4  a git command failed`,
+	Example: `  gitter cr
+  gitter cr src/ -- docs/
+  gitter cr --tracked-only
+  gitter cr --interactive
+  gitter cr --yes --all -m "update dependencies"
+  gitter cr --dry-run --no-llm
+  gitter cr --split --split-by llm
+  gitter cr --amend
+  gitter cr --fixup pick`,
 	RunE: handleCrCommand,
-
 }
 
-
-
 func init() {
-
 	rootCmd.AddCommand(crCmd)
 
+	crCmd.Flags().BoolVarP(&crYes, "yes", "y", false, "Answer yes to every question and commit the generated message without review")
+	crCmd.Flags().BoolVarP(&crAll, "all", "A", false, "Stage all changes, including untracked files, without asking")
+	crCmd.Flags().BoolVarP(&crTrackedOnly, "tracked-only", "u", false, "Stage changes to tracked files only, never untracked ones")
+	crCmd.Flags().StringVarP(&crMessage, "message", "m", "", "The short description of the change used to generate the commit message")
+	crCmd.Flags().BoolVar(&crNoLLM, "no-llm", false, "Use the template message generator even if an LLM is configured")
+	crCmd.Flags().BoolVar(&crDryRun, "dry-run", false, "Print the generated message without committing or changing the index")
+	crCmd.Flags().BoolVar(&crSplit, "split", false, "Split the staged changes into several commits")
+	crCmd.Flags().StringVar(&crSplitBy, "split-by", "dir", "How --split groups changes: dir, type or llm")
+	crCmd.Flags().BoolVarP(&crInteractive, "interactive", "i", false, "Choose the files and hunks to stage before generating the message")
+	crCmd.Flags().BoolVar(&crAmend, "amend", false, "Amend the last commit, refining its message for the combined changes")
+	crCmd.Flags().StringVar(&crFixup, "fixup", "", "Commit the staged changes as a fixup! commit for this revision, or \"pick\" to choose one")
+	crCmd.Flags().BoolVar(&crAllowSecrets, "allow-secrets", false, "Commit even if the staged changes appear to contain secrets")
+	crCmd.Flags().BoolVar(&crForce, "force", false, "Allow --amend and --fixup to rewrite commits already pushed to the upstream branch")
 }
 
-
-
-// fileChangeStats holds the statistics for a single changed file.
-
-type fileChangeStats struct {
-
-	filePath     string
-
-	linesChanged int
-
-	charsChanged int
-
-}
-
-
-
 func handleCrCommand(cmd *cobra.Command, args []string) error {
+	err := runCr(args)
+	if errors.Is(err, errNotInteractive) {
+		return fmt.Errorf("%w; use --yes, --all and --message to run cr non-interactively", err)
+	}
+	return err
+}
 
+func runCr(pathspecs []string) error {
 	// 1. Check if we are in a git repository.
-
 	gitCheckCmd := execCommand("git", "rev-parse", "--is-inside-work-tree")
-
 	if err := gitCheckCmd.Run(); err != nil {
-
-		return fmt.Errorf("not a git repository: %w", err)
-
+		return withExitCode(exitGitFailure, fmt.Errorf("not a git repository: %w", err))
 	}
 
+	stageFirst := crAll || crTrackedOnly || len(pathspecs) > 0
+	if crDryRun && stageFirst {
+		return fmt.Errorf("--dry-run does not change the index and cannot be combined with --all, --tracked-only or pathspecs")
+	}
+	if crAll && crTrackedOnly {
+		return fmt.Errorf("--all and --tracked-only cannot be combined")
+	}
+	if crInteractive && (crDryRun || crYes || crAll || crTrackedOnly) {
+		return fmt.Errorf("--interactive asks which changes to stage and cannot be combined with --dry-run, --yes, --all or --tracked-only")
+	}
+	if crAmend && crFixup != "" {
+		return fmt.Errorf("--amend and --fixup cannot be combined")
+	}
+	if crSplit && (crAmend || crFixup != "") {
+		return fmt.Errorf("--split cannot be combined with --amend or --fixup")
+	}
+	if crFixup != "" && crMessage != "" {
+		return fmt.Errorf("--fixup uses the subject of the target commit and cannot be combined with --message")
+	}
+	if !slices.Contains(splitStrategies, crSplitBy) {
+		return fmt.Errorf("unknown --split-by %q, use %s", crSplitBy, strings.Join(splitStrategies, ", "))
+	}
 
+	prompt := newPrompter()
 
-	reader := bufio.NewReader(os.Stdin)
-
-
-
-	// 2. Check for staged changes.
+	// Find the commit to rewrite first, so nothing is staged for nothing.
+	var amendFrom, previousMessage string
+	if crAmend {
+		var err error
+		if amendFrom, err = amendBase(); err != nil {
+			return err
+		}
+		if err := checkNotPushed("HEAD", "amend"); err != nil {
+			return err
+		}
+		if previousMessage, err = commitMessage("HEAD"); err != nil {
+			return err
+		}
+	}
+	var fixupTarget loggedCommit
+	if crFixup != "" {
+		var err error
+		if fixupTarget, err = resolveFixupTarget(prompt, crFixup); err != nil {
+			return err
+		}
+		if err := checkNotPushed(fixupTarget.hash, "fix up"); err != nil {
+			return err
+		}
+	}
 
+	// 2. Stage what was asked for, then check for staged changes.
+	switch {
+	case crInteractive:
+		if _, err := selectChanges(prompt, pathspecs); err != nil {
+			return err
+		}
+	case stageFirst:
+		if err := stageChanges(prompt, pathspecs); err != nil {
+			return err
+		}
+	}
 	stagedCheckCmd := execCommand("git", "diff", "--cached", "--quiet")
+	nothingStaged := stagedCheckCmd.Run() == nil // Exits with 1 if there are staged changes
+	if nothingStaged && crAmend {
+		fmt.Println("No new changes staged; rewording the last commit.")
+	} else if nothingStaged {
+		if crDryRun {
+			fmt.Println("No files are currently staged; nothing to preview.")
+			return withExitCode(exitNothingToCommit, nil)
+		}
+		if crInteractive {
+			fmt.Println("Operation cancelled. No files were staged.")
+			return withExitCode(exitCancelled, nil)
+		}
+		if stageFirst {
+			fmt.Println("No changes to commit.")
+			return withExitCode(exitNothingToCommit, nil)
+		}
 
-	if stagedCheckCmd.Run() != nil { // Exits with 1 if no staged changes
-
-		fmt.Print("No files are currently staged. Would you like to stage all changed files? (y/n): ")
-
-		stageAllInput, _ := reader.ReadString('\n')
-
-		stageAllInput = strings.TrimSpace(strings.ToLower(stageAllInput))
-
-
-
-		if stageAllInput == "y" {
-
-			fmt.Println("Staging all changed files...")
-
-			if err := execCommand("git", "add", ".").Run(); err != nil {
-
-				return fmt.Errorf("error staging changes: %w", err)
-
+		choice := "a"
+		if !crYes {
+			var err error
+			choice, err = prompt.ask("No files are currently staged. Stage [a]ll changed files, [s]elect files and hunks, or [q]uit? ")
+			if err != nil {
+				return err
 			}
+		}
 
-		} else {
-
+		switch strings.ToLower(choice) {
+		case "a", "all", "y", "yes":
+			if err := stageChanges(prompt, nil); err != nil {
+				return err
+			}
+		case "s", "select":
+			staged, err := selectChanges(prompt, nil)
+			if err != nil {
+				return err
+			}
+			if !staged {
+				fmt.Println("Operation cancelled. No files were staged.")
+				return withExitCode(exitCancelled, nil)
+			}
+		default:
 			fmt.Println("Operation cancelled. No files were staged.")
-
-			return nil
-
+			return withExitCode(exitCancelled, nil)
 		}
-
 	} else {
-
 		fmt.Println("Working on currently staged changes.")
-
 	}
 
-
-
-	// 3. Get the diff of staged changes.
-
+	// 3. Get the diff of staged changes, together with the last commit's
+	// changes when amending it.
 	fmt.Println("Generating diff...")
-
-	diffCmd := execCommand("git", "diff", "--staged")
-
+	diffArgs := []string{"diff", "--staged"}
+	if crAmend {
+		diffArgs = append(diffArgs, amendFrom)
+	}
+	diffCmd := execCommand("git", diffArgs...)
 	diffOutputBytes, err := diffCmd.Output()
-
 	if err != nil {
-
-		return fmt.Errorf("error getting diff: %w", err)
-
+		return withExitCode(exitGitFailure, fmt.Errorf("error getting diff: %w", err))
 	}
-
 	diffOutput := string(diffOutputBytes)
 
-
-
 	if strings.TrimSpace(diffOutput) == "" {
-
 		fmt.Println("No changes to commit.")
-
-		execCommand("git", "reset").Run()
-
-		return nil
-
+		if !crAmend {
+			execCommand("git", "reset").Run()
+		}
+		return withExitCode(exitNothingToCommit, nil)
 	}
 
+	// 4. Parse the diff to get per-file stats.
+	files, err := diff.Parse(diffOutput)
+	if err != nil {
+		if crSplit {
+			return fmt.Errorf("cannot split a diff that could not be parsed: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: could not parse diff, file stats will be missing: %v\n", err)
+	}
 
+	if err := checkStagedSecrets(diffOutput, files); err != nil {
+		return err
+	}
 
-	// 4. Parse the diff to get stats.
-
-	stats := parseDiffStats(diffOutput)
-
-
+	if crFixup != "" {
+		return commitFixup(fixupTarget)
+	}
+	if crSplit {
+		return runSplit(prompt, files)
+	}
 
 	// 5. Ask the user for a commit message.
+	userMessage := strings.TrimSpace(crMessage)
+	if userMessage == "" && !crYes {
+		question := "Please enter a commit message (or press Enter for a default):\n> "
+		if crAmend {
+			question = "Please enter a commit message (or press Enter to refine the current one):\n> "
+		}
+		userMessage, err = prompt.ask(question)
+		if err != nil {
+			return err
+		}
+	}
 
-	fmt.Print("Please enter a commit message (or press Enter for a default):\n> ")
-
-	userMessage, _ := reader.ReadString('\n')
-
-	userMessage = strings.TrimSpace(userMessage)
-
-
-
-	if userMessage == "" {
-
+	// When amending, the current message is the starting point: it is the
+	// hint itself if the user gave none, and context for the LLM otherwise.
+	amendContext := ""
+	switch {
+	case userMessage == "" && crAmend:
+		userMessage = previousMessage
+	case userMessage == "":
 		userMessage = createDefaultCommitMessage()
-
 		fmt.Printf("No commit message provided. Using default: \"%s\"\n", userMessage)
-
+	case crAmend:
+		amendContext = "\n\nThis amends a commit whose current message is:\n" + previousMessage
 	}
 
-
-
 	// 6. Generate a nice commit message.
-
-	generatedMessage := generateCommitMessage(userMessage, diffOutput, stats)
-
-
-
-	// 7. Ask for confirmation.
-
-	fmt.Println("\n--- Generated Commit Message ---")
-
-	fmt.Print(generatedMessage)
-
-	fmt.Println("\n--------------------------------")
-
-	fmt.Print("Confirm commit with this message? (y/n): ")
-
-	confirmInput, _ := reader.ReadString('\n')
-
-	confirmInput = strings.TrimSpace(strings.ToLower(confirmInput))
-
-
-
-	if confirmInput == "y" {
-
-		// 8. Commit.
-
-		fmt.Println("Committing...")
-
-		commitCmd := execCommand("git", "commit", "-m", generatedMessage)
-
-		if err := commitCmd.Run(); err != nil {
-
-			return fmt.Errorf("error committing: %w", err)
-
+	generate := func(userMessage string) string {
+		if crNoLLM {
+			return generateSimpleCommitMessage(userMessage, files)
 		}
+		return generateCommitMessage(userMessage+amendContext, diffOutput, files)
+	}
+	generatedMessage := generate(userMessage)
 
-		fmt.Println("Commit successful.")
+	if crDryRun {
+		fmt.Println("\n--- Generated Commit Message ---")
+		fmt.Print(generatedMessage)
+		fmt.Println("\n--------------------------------")
+		fmt.Println("Dry run: nothing was committed.")
+		return nil
+	}
 
-	} else {
+	// 7. Let the user review the message until they accept or abort it.
+	finalMessage, accepted := generatedMessage, true
+	if !crYes {
+		regenerate := func(hint string) string {
+			return generate(userMessage + "\n\nAdditional guidance: " + hint)
+		}
+		template := func() string {
+			return generateSimpleCommitMessage(userMessage, files)
+		}
+		finalMessage, accepted, err = reviewCommitMessage(prompt, generatedMessage, regenerate, template)
+		if err != nil {
+			return err
+		}
+	}
 
+	if !accepted {
 		fmt.Println("Commit cancelled. Changes are still staged.")
-
-		fmt.Print("Would you like to unstage the changes? (y/n): ")
-
-		unstageInput, _ := reader.ReadString('\n')
-
-		unstageInput = strings.TrimSpace(strings.ToLower(unstageInput))
-
-		if unstageInput == "y" {
-
+		unstage, err := prompt.confirm("Would you like to unstage the changes?")
+		if err == nil && unstage {
 			if err := execCommand("git", "reset").Run(); err != nil {
-
 				fmt.Fprintf(os.Stderr, "Error unstaging changes: %v\n", err) // Print, but don't exit if unstage fails
-
 			} else {
-
 				fmt.Println("Changes have been unstaged.")
-
 			}
-
 		}
-
+		return withExitCode(exitCancelled, nil)
 	}
 
+	// 8. Commit.
+	fmt.Println("Committing...")
+	commitArgs := []string{"commit", "-m", finalMessage}
+	if crAmend {
+		commitArgs = append(commitArgs, "--amend")
+	}
+	commitCmd := execCommand("git", commitArgs...)
+	if err := commitCmd.Run(); err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("error committing: %w", err))
+	}
+	fmt.Println("Commit successful.")
 	return nil
-
 }
 
-func parseDiffStats(diffOutput string) []fileChangeStats {
-	fileDiffs := strings.Split(diffOutput, "diff --git")
-	var stats []fileChangeStats
-	for _, fileDiff := range fileDiffs {
-		if strings.TrimSpace(fileDiff) == "" {
-			continue
-		}
+// stageChanges stages the changes to tracked files matching pathspecs, or
+// to all tracked files if there are none. Matching untracked files are
+// listed and staged as well with --all or --yes, or once the
+// user confirms them; --tracked-only leaves them alone.
+func stageChanges(prompt *prompter, pathspecs []string) error {
+	untracked, err := untrackedFiles(pathspecs)
+	if err != nil {
+		return err
+	}
 
-	
-		lines := strings.Split(fileDiff, "\n")
-		var currentFile string
-		var linesChanged, charsChanged int
-
-		// Find the file path from the "+++ b/" line
-		for _, line := range lines {
-			if strings.HasPrefix(line, "+++ b/") {
-				currentFile = strings.TrimPrefix(line, "+++ b/")
-				// Handle cases where the file is deleted (e.g., +++ b/dev/null)
-				if currentFile == "/dev/null" {
-					currentFile = "" // Or handle as appropriate for deletions
-				}
-				break
-			}
+	fmt.Println("Staging changes to tracked files...")
+	output, err := execCommand("git", append([]string{"add", "--update", "--"}, pathspecs...)...).CombinedOutput()
+	if err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("error staging changes: %w\n%s", err, strings.TrimSpace(string(output))))
+	}
+	if len(untracked) == 0 || crTrackedOnly {
+		return nil
+	}
+
+	fmt.Println("Untracked files:")
+	for _, path := range untracked {
+		fmt.Printf("  %s\n", path)
+	}
+	stage := crAll || crYes
+	if !stage {
+		stage, err = prompt.confirm(fmt.Sprintf("Stage these %d untracked files too?", len(untracked)))
+		if err != nil {
+			return err
 		}
-		if currentFile == "" {
-			continue // Skip if no valid file path found (e.g., for deletions where we don't want stats)
+	}
+	if !stage {
+		fmt.Println("Leaving the untracked files out.")
+		return nil
+	}
+	output, err = execCommand("git", append([]string{"add", "--"}, untracked...)...).CombinedOutput()
+	if err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("error staging untracked files: %w\n%s", err, strings.TrimSpace(string(output))))
+	}
+	return nil
+}
+
+// reviewCommitMessage shows message and asks the user what to do with it
+// until they accept or abort. It returns the final message and whether it
+// was accepted.
+func reviewCommitMessage(prompt *prompter, message string, regenerate func(hint string) string, template func() string) (string, bool, error) {
+	for {
+		fmt.Println("\n--- Generated Commit Message ---")
+		fmt.Print(message)
+		fmt.Println("\n--------------------------------")
+		choice, err := prompt.ask("[a]ccept, [e]dit, [r]egenerate with a hint, use [t]emplate, [q]uit: ")
+		if err != nil {
+			return message, false, err
 		}
 
-		for _, line := range lines {
-			if (strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "++")) ||
-				(strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---")) {
-				linesChanged++
-				charsChanged += len(strings.TrimSpace(line[1:]))
+		switch strings.ToLower(choice) {
+		case "a", "accept", "y", "yes":
+			return message, true, nil
+		case "e", "edit":
+			edited, err := editCommitMessage(message)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not edit commit message: %v\n", err)
+				continue
 			}
+			if edited == "" {
+				fmt.Println("Edited message is empty, keeping the previous message.")
+				continue
+			}
+			message = edited
+		case "r", "regenerate":
+			hint, err := prompt.ask("Hint for the new message (e.g. 'mention the API change'):\n> ")
+			if err != nil {
+				return message, false, err
+			}
+			message = regenerate(hint)
+		case "t", "template":
+			message = template()
+		case "q", "quit", "abort", "n", "no":
+			return message, false, nil
+		default:
+			fmt.Printf("Unknown choice %q.\n", choice)
 		}
-		stats = append(stats, fileChangeStats{
-			filePath:     currentFile,
-			linesChanged: linesChanged,
-			charsChanged: charsChanged,
-		})
 	}
-	return stats
 }
 
 func createDefaultCommitMessage() string {
@@ -292,10 +459,11 @@ func createDefaultCommitMessage() string {
 	return fmt.Sprintf("new git commit on %s %s", repoName, dateStr)
 }
 
-// newLLMClientFunc is a package-level variable to allow mocking llm.NewLLMClient in tests.
-var newLLMClientFunc = llm.NewLLMClient
+// newLLMClientFunc is a package-level variable to allow mocking the LLM client in tests.
+// The real client masks likely secrets before sending anything.
+var newLLMClientFunc = newRedactingLLMClient
 
-func generateCommitMessage(userMessage, diffOutput string, stats []fileChangeStats) string {
+func generateCommitMessage(userMessage, diffOutput string, files []*diff.File) string {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: could not load config, using simple message generator: %v\n", err)
@@ -303,18 +471,44 @@ func generateCommitMessage(userMessage, diffOutput string, stats []fileChangeSta
 
 	llmClient, err := newLLMClientFunc(cfg)
 	if err == nil {
-		fmt.Printf("Generating commit message with %s...\n", cfg.Provider)
-		llmMessage, err := llmClient.GenerateCommitMessage(diffOutput, userMessage)
+		// Ctrl-C cancels the request instead of killing gitter, so the
+		// commit can still go ahead with the simple message.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		promptDiff := prepareDiffForLLM(ctx, os.Stdout, llmClient, cfg, diffOutput)
+		llmMessage, err := streamCommitMessage(ctx, llmClient, cfg.Provider, promptDiff, userMessage)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			fmt.Fprintln(os.Stderr, "LLM request cancelled, falling back to simple generator.")
+			return generateSimpleCommitMessage(userMessage, files)
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: LLM message generation failed, falling back to simple generator: %v\n", err)
-			return generateSimpleCommitMessage(userMessage, stats)
+			return generateSimpleCommitMessage(userMessage, files)
+		}
+
+		// Give the model one chance to fix a message that breaks the format.
+		rules := conventional.DefaultRules()
+		if violations := conventional.Lint(llmMessage, rules); len(violations) > 0 {
+			fmt.Fprintf(os.Stderr, "Generated message does not follow Conventional Commits, retrying once:\n%s", formatViolations(violations))
+			feedback := fmt.Sprintf("%s\n\nA previous attempt produced this message:\n%s\nIt was rejected because:\n%sWrite a corrected message.",
+				userMessage, llmMessage, formatViolations(violations))
+			retryMessage, err := streamCommitMessage(ctx, llmClient, cfg.Provider, promptDiff, feedback)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: retry failed, keeping the first message: %v\n", err)
+				return llmMessage
+			}
+			if violations := conventional.Lint(retryMessage, rules); len(violations) > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: the regenerated message still does not follow Conventional Commits:\n%s", formatViolations(violations))
+			}
+			return retryMessage
 		}
 		return llmMessage
 	}
-	return generateSimpleCommitMessage(userMessage, stats)
+	return generateSimpleCommitMessage(userMessage, files)
 }
 
-func generateSimpleCommitMessage(userMessage string, stats []fileChangeStats) string {
+func generateSimpleCommitMessage(userMessage string, files []*diff.File) string {
 	commitTitle := userMessage
 	commitBody := ""
 
@@ -332,14 +526,37 @@ func generateSimpleCommitMessage(userMessage string, stats []fileChangeStats) st
 		b.WriteString(commitBody + "\n\n")
 	}
 
-	if len(stats) > 0 {
+	if len(files) > 0 {
 		b.WriteString("Changes:\n")
-		for _, stat := range stats {
-			b.WriteString(fmt.Sprintf("- %s (%d lines, %d characters)\n", stat.filePath, stat.linesChanged, stat.charsChanged))
+		for _, file := range files {
+			b.WriteString("- " + describeFileChange(file) + "\n")
 		}
 	} else {
 		b.WriteString("No specific file changes detected in diff.\n")
 	}
 
 	return b.String()
-}
\ No newline at end of file
+}
+
+// describeFileChange summarises a file's change for the template message,
+// e.g. "cmd/cr.go (modified, +12 -3)" or "old.go -> new.go (renamed, 90% similar)".
+func describeFileChange(file *diff.File) string {
+	name := file.Path()
+	if file.Change == diff.Renamed || file.Change == diff.Copied {
+		name = file.OldPath + " -> " + file.NewPath
+	}
+
+	details := []string{file.Change.String()}
+	switch {
+	case file.Binary:
+		details = append(details, "binary")
+	case file.Added() > 0 || file.Removed() > 0:
+		details = append(details, fmt.Sprintf("+%d -%d", file.Added(), file.Removed()))
+	case file.Similarity > 0:
+		details = append(details, fmt.Sprintf("%d%% similar", file.Similarity))
+	}
+	if file.ModeChanged() {
+		details = append(details, fmt.Sprintf("mode %s -> %s", file.OldMode, file.NewMode))
+	}
+	return fmt.Sprintf("%s (%s)", name, strings.Join(details, ", "))
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// editorHelp is appended to the message opened in the editor. Like git, the
+// comment lines are stripped again when the editor exits.
+const editorHelp = `
+# Please edit the commit message for your changes. Lines starting
+# with '#' will be ignored, and an empty message keeps the previous one.
+`
+
+// editCommitMessage opens message in the editor git would use for a commit
+// (GIT_EDITOR, core.editor, VISUAL, EDITOR, then vi) and returns the edited
+// text with comment lines stripped.
+func editCommitMessage(message string) (string, error) {
+	editor, err := execCommand("git", "var", "GIT_EDITOR").Output()
+	if err != nil {
+		return "", fmt.Errorf("could not determine editor: %w", err)
+	}
+
+	// Editing .git/COMMIT_EDITMSG, as git does, gives editors their usual
+	// commit message highlighting.
+	pathOutput, err := execCommand("git", "rev-parse", "--git-path", "COMMIT_EDITMSG").Output()
+	if err != nil {
+		return "", fmt.Errorf("could not locate COMMIT_EDITMSG: %w", err)
+	}
+	path := strings.TrimSpace(string(pathOutput))
+
+	if err := os.WriteFile(path, []byte(strings.TrimRight(message, "\n")+"\n"+editorHelp), 0644); err != nil {
+		return "", fmt.Errorf("could not write %s: %w", path, err)
+	}
+
+	// The editor setting is a shell snippet (e.g. "code --wait"), so run it through sh like git does.
+	editorCmd := execCommand("sh", "-c", strings.TrimSpace(string(editor))+` "$@"`, "editor", path)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return "", fmt.Errorf("editor failed: %w", err)
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %w", path, err)
+	}
+	return stripCommentLines(string(edited)), nil
+}
+
+// scissorsLine follows the comment character on the line below which
+// "git commit -v" appends the diff. Git drops that line and everything after it.
+const scissorsLine = " ------------------------ >8 ------------------------"
+
+// stripCommentLines cleans up a commit message the way "git commit
+// --cleanup=strip" does: comment lines and trailing whitespace are removed,
+// runs of blank lines are collapsed, and leading and trailing blank lines
+// are dropped.
+func stripCommentLines(message string) string {
+	return cleanMessage(message, "#")
+}
+
+// cleanMessage is stripCommentLines for comment lines starting with
+// commentChar. The message is first cut at the scissors line, if any.
+func cleanMessage(message string, commentChar string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(message, "\n") {
+		if line == commentChar+scissorsLine {
+			break
+		}
+		if strings.HasPrefix(line, commentChar) {
+			continue
+		}
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
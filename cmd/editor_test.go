@@ -0,0 +1,43 @@
+package cmd
+
+import "testing"
+
+func TestStripCommentLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "comments and trailing whitespace",
+			input: "feat: add menu   \n\n# Please edit the commit message\nBody line\t\n# another comment\n",
+			want:  "feat: add menu\n\nBody line\n",
+		},
+		{
+			name:  "blank line runs collapsed and trimmed",
+			input: "\n\nfix: thing\n\n\n\nbody\n\n\n",
+			want:  "fix: thing\n\nbody\n",
+		},
+		{
+			name:  "only comments",
+			input: "# nothing here\n\n# at all\n",
+			want:  "",
+		},
+		{
+			name:  "diff below the scissors line dropped",
+			input: "feat: add menu\n# Please edit the commit message\n# ------------------------ >8 ------------------------\n# Do not modify or remove the line above.\ndiff --git a/menu.go b/menu.go\n+package menu\n",
+			want:  "feat: add menu\n",
+		},
+		{
+			name:  "hash inside a line is kept",
+			input: "fix: handle #123\n",
+			want:  "fix: handle #123\n",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := stripCommentLines(tt.input); got != tt.want {
+			t.Errorf("%s: stripCommentLines() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
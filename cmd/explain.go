@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/biswajitpain/gitter/internal/config"
+	"github.com/biswajitpain/gitter/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+var explainRange string
+
+// explainContextLines is how many lines of unchanged code surround each
+// change, so the model sees what the touched code belongs to.
+const explainContextLines = 10
+
+// explainCmd represents the explain command
+var explainCmd = &cobra.Command{
+	Use:   "explain [<commit>]",
+	Short: "Explain in plain language what a commit or range of commits did",
+	Long: `Explain what a commit did. Its message, its diff and the code around each
+change are sent to the configured LLM, which replies with a plain-language
+explanation aimed at someone new to the codebase.
+
+With --range, a series of commits is explained as one narrative from
+their messages and combined diff. Very large changes are abbreviated
+the same way as for 'gitter cr': generated files are listed by name and
+the largest files are summarised separately.`,
+	Example: `  gitter explain HEAD
+  gitter explain 3f2c1ab
+  gitter explain --range v1.2.0..v1.3.0`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: handleExplainCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+
+	explainCmd.Flags().StringVar(&explainRange, "range", "", "Explain the commits in this revision range as one narrative, e.g. main..HEAD")
+}
+
+func handleExplainCommand(cmd *cobra.Command, args []string) error {
+	if (explainRange == "") == (len(args) == 0) {
+		return errors.New("give either a commit or --range")
+	}
+
+	var logArgs, diffArgs []string
+	target := explainRange
+	contextLines := fmt.Sprintf("-U%d", explainContextLines)
+	if explainRange != "" {
+		logArgs = []string{"log", "--reverse", "--format=" + explainLogFormat, explainRange}
+		diffArgs = []string{"diff", contextLines, explainRange}
+	} else {
+		// Merges are explained by what they brought into the first parent.
+		target = args[0]
+		logArgs = []string{"log", "--no-walk", "--format=" + explainLogFormat, args[0]}
+		diffArgs = []string{"show", "-m", "--first-parent", "--format=", contextLines, args[0]}
+	}
+
+	logOutput, err := execCommand("git", logArgs...).Output()
+	if err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("error reading commit log: %w", err))
+	}
+	commits := strings.Count(string(logOutput), "\x1e")
+	if commits == 0 {
+		return withExitCode(exitNothingToCommit, fmt.Errorf("no commits in %s", target))
+	}
+	diffOutput, err := execCommand("git", diffArgs...).Output()
+	if err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("error getting diff: %w", err))
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load config: %v\n", err)
+	}
+	llmClient, err := newLLMClientFunc(cfg)
+	if err != nil {
+		return fmt.Errorf("gitter explain needs an LLM, set one up with 'gitter config': %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	promptDiff := prepareDiffForLLM(ctx, os.Stderr, llmClient, cfg, string(diffOutput))
+
+	fmt.Fprintf(os.Stderr, "Explaining %d commit(s) with %s (press Ctrl-C to cancel)...\n\n", commits, cfg.Provider)
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	explanation, err := llm.ExplainChanges(ctx, llmClient, strings.ReplaceAll(string(logOutput), "\x1e", ""), promptDiff, commits)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return withExitCode(exitCancelled, errors.New("explanation cancelled"))
+	}
+	if err != nil {
+		return fmt.Errorf("LLM request failed: %w", err)
+	}
+	fmt.Println(strings.TrimSpace(explanation))
+	return nil
+}
+
+// explainLogFormat shows each commit like 'git log' does, followed by a
+// record separator so commits can be counted.
+const explainLogFormat = "commit %H%nAuthor: %an <%ae>%nDate:   %ad%n%n%B%x1e"
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// runExplain runs the explain command with the given range and arguments.
+func runExplain(t *testing.T, rng string, args ...string) (string, error) {
+	old := explainRange
+	explainRange = rng
+	defer func() { explainRange = old }()
+
+	var err error
+	output := captureStdout(func() {
+		captureStderr(func() {
+			err = handleExplainCommand(explainCmd, args)
+		})
+	})
+	return output, err
+}
+
+func TestExplain_CommitOrRange(t *testing.T) {
+	inTestRepo(t)
+	fake := useFakeLLM(t, "It adds a README.")
+
+	for _, tt := range []struct {
+		rng  string
+		args []string
+	}{
+		{"", nil},
+		{"HEAD~1..HEAD", []string{"HEAD"}},
+	} {
+		if _, err := runExplain(t, tt.rng, tt.args...); err == nil || !strings.Contains(err.Error(), "either a commit or --range") {
+			t.Errorf("explain --range %q %q error = %v, want a usage error", tt.rng, tt.args, err)
+		}
+	}
+	if len(fake.prompts) != 0 {
+		t.Errorf("usage errors sent %d prompts", len(fake.prompts))
+	}
+
+	output, err := runExplain(t, "", "HEAD")
+	if err != nil {
+		t.Fatalf("explain HEAD error: %v", err)
+	}
+	if strings.TrimSpace(output) != "It adds a README." {
+		t.Errorf("explain HEAD printed %q", output)
+	}
+}
+
+func TestExplain_NoCommits(t *testing.T) {
+	inTestRepo(t)
+	useFakeLLM(t, "")
+
+	for _, tt := range []struct{ rng, arg string }{{"HEAD..HEAD", ""}, {"", "HEAD..HEAD"}} {
+		var err error
+		if tt.arg != "" {
+			_, err = runExplain(t, tt.rng, tt.arg)
+		} else {
+			_, err = runExplain(t, tt.rng)
+		}
+		if exitCodeOf(err) != exitNothingToCommit || err == nil || !strings.Contains(err.Error(), "no commits in HEAD..HEAD") {
+			t.Errorf("explain %q %q = %v (exit %d), want no commits in HEAD..HEAD", tt.rng, tt.arg, err, exitCodeOf(err))
+		}
+	}
+}
+
+func TestExplain_MergeShowsFirstParentDiff(t *testing.T) {
+	inTestRepo(t)
+	fake := useFakeLLM(t, "It merges a feature.")
+
+	git(t, "checkout", "-q", "-b", "feature")
+	writeFile(t, "feature.go", "package feature\n")
+	git(t, "add", "feature.go")
+	git(t, "commit", "-q", "-m", "Add feature")
+	git(t, "checkout", "-q", "-")
+	writeFile(t, "main.go", "package main\n")
+	git(t, "add", "main.go")
+	git(t, "commit", "-q", "-m", "Add main")
+	git(t, "merge", "-q", "--no-ff", "-m", "Merge feature", "feature")
+
+	if _, err := runExplain(t, "", "HEAD"); err != nil {
+		t.Fatalf("explain HEAD error: %v", err)
+	}
+	prompt := fake.prompts[len(fake.prompts)-1]
+	if !strings.Contains(prompt, "+package feature") {
+		t.Errorf("the prompt lacks what the merge brought in:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "+package main") {
+		t.Errorf("the prompt shows changes already on the first parent:\n%s", prompt)
+	}
+}
+
+func TestExplain_AbbreviatesLargeCommits(t *testing.T) {
+	inTestRepo(t)
+	fake := useFakeLLM(t, "A summary.")
+	t.Setenv("GITTER_MAX_DIFF_TOKENS", "500")
+
+	var sum, big strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&sum, "example.com/mod%d v1.0.0 h1:lockfilehash%d=\n", i, i)
+		fmt.Fprintf(&big, "var generatedValue%d = %d\n", i, i)
+	}
+	writeFile(t, "go.sum", sum.String())
+	writeFile(t, "big.go", "package main\n\n"+big.String())
+	writeFile(t, "main.go", "package main\n")
+	git(t, "add", "-A")
+	git(t, "commit", "-q", "-m", "Add dependencies")
+
+	if _, err := runExplain(t, "", "HEAD"); err != nil {
+		t.Fatalf("explain HEAD error: %v", err)
+	}
+	prompt := fake.prompts[len(fake.prompts)-1]
+	for _, line := range []string{"h1:lockfilehash1=", "generatedValue100"} {
+		if strings.Contains(prompt, line) {
+			t.Errorf("the prompt was not abbreviated, it contains %q", line)
+		}
+	}
+	for _, path := range []string{"go.sum", "big.go", "+package main"} {
+		if !strings.Contains(prompt, path) {
+			t.Errorf("the prompt lacks %q:\n%s", path, prompt)
+		}
+	}
+}
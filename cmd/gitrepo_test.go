@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/biswajitpain/gitter/internal/config"
+	"github.com/biswajitpain/gitter/internal/llm"
+)
+
+// inTestRepo runs the test in a new git repository with one commit, a
+// fresh HOME and no gitter variables in the environment.
+func inTestRepo(t *testing.T) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	for _, v := range os.Environ() {
+		name, _, _ := strings.Cut(v, "=")
+		if strings.HasPrefix(name, "GITTER_") || strings.HasSuffix(name, "_API_KEY") {
+			t.Setenv(name, "")
+		}
+	}
+
+	repo := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	git(t, "init", "-q")
+	git(t, "config", "user.name", "Test")
+	git(t, "config", "user.email", "test@example.com")
+	writeFile(t, "README.md", "hello\n")
+	git(t, "add", "README.md")
+	git(t, "commit", "-q", "-m", "Initial commit")
+	return repo
+}
+
+// git runs a git command in the current directory and returns its output.
+func git(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commitAll commits every change in the work tree with message.
+func commitAll(t *testing.T, message string) {
+	t.Helper()
+	git(t, "add", "-A")
+	git(t, "commit", "-q", "-m", message)
+}
+
+// writeFile writes content to path, creating its directory.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if i := strings.LastIndexByte(path, '/'); i > 0 {
+		if err := os.MkdirAll(path[:i], 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeLLM records the prompts it is sent and answers each with reply.
+type fakeLLM struct {
+	reply   string
+	prompts []string
+}
+
+func (f *fakeLLM) GenerateCommitMessage(diff string, userMessage string) (string, error) {
+	f.prompts = append(f.prompts, diff)
+	return f.reply, nil
+}
+
+func (f *fakeLLM) StreamCommitMessage(ctx context.Context, diff string, userMessage string, onToken func(string)) (string, error) {
+	f.prompts = append(f.prompts, diff)
+	onToken(f.reply)
+	return f.reply, nil
+}
+
+func (f *fakeLLM) Complete(ctx context.Context, system string, prompt string) (string, error) {
+	f.prompts = append(f.prompts, prompt)
+	return f.reply, nil
+}
+
+// useFakeLLM makes commands use a fakeLLM for the rest of the test.
+func useFakeLLM(t *testing.T, reply string) *fakeLLM {
+	fake := &fakeLLM{reply: reply}
+	old := newLLMClientFunc
+	newLLMClientFunc = func(config.Config) (llm.LLMClient, error) { return fake, nil }
+	t.Cleanup(func() { newLLMClientFunc = old })
+	return fake
+}
+
+// exitCodeOf returns the exit code err carries, as Execute would.
+func exitCodeOf(err error) int {
+	var exitErr *exitCodeError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.code
+	}
+	return exitError
+}
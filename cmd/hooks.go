@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/biswajitpain/gitter/internal/conventional"
+	"github.com/biswajitpain/gitter/internal/diff"
+	"github.com/spf13/cobra"
+)
+
+// hookMarker identifies hook scripts written by gitter, so they can be
+// updated and removed without touching anyone else's hooks.
+const hookMarker = "# Installed by gitter."
+
+// backupSuffix is appended to an existing hook when gitter replaces it. The
+// backup keeps running before gitter's own check.
+const backupSuffix = ".pre-gitter"
+
+// managedHooks are the git hooks gitter installs.
+var managedHooks = []string{"prepare-commit-msg", "commit-msg"}
+
+// hooksCmd represents the hooks command
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Install or remove gitter's git hooks",
+	Long: `Install git hooks so plain 'git commit' (and IDEs that use it) get the
+same help as 'gitter cr':
+
+  prepare-commit-msg  pre-fills the commit message from the staged diff
+  commit-msg          rejects messages that break Conventional Commits
+
+Hooks are written to the repository's hooks directory, which respects
+core.hooksPath. Existing hooks are kept as <hook>.pre-gitter and still run
+first. Use 'git commit --no-verify' to skip the commit-msg check.`,
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the prepare-commit-msg and commit-msg hooks",
+	Args:  cobra.NoArgs,
+	RunE:  handleHooksInstall,
+}
+
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove gitter's hooks and restore any hooks they replaced",
+	Args:  cobra.NoArgs,
+	RunE:  handleHooksUninstall,
+}
+
+// hookCmd is the entry point the installed hook scripts call.
+var hookCmd = &cobra.Command{
+	Use:    "hook",
+	Short:  "Run a gitter git hook",
+	Hidden: true,
+}
+
+var prepareCommitMsgHookCmd = &cobra.Command{
+	Use:   "prepare-commit-msg <file> [<source> [<commit>]]",
+	Short: "Pre-fill the commit message from the staged diff",
+	Args:  cobra.RangeArgs(1, 3),
+	RunE:  handlePrepareCommitMsgHook,
+}
+
+var commitMsgHookCmd = &cobra.Command{
+	Use:   "commit-msg <file>",
+	Short: "Check the commit message against Conventional Commits",
+	Args:  cobra.ExactArgs(1),
+	RunE:  handleCommitMsgHook,
+}
+
+func init() {
+	rootCmd.AddCommand(hooksCmd)
+	hooksCmd.AddCommand(hooksInstallCmd, hooksUninstallCmd)
+
+	rootCmd.AddCommand(hookCmd)
+	hookCmd.AddCommand(prepareCommitMsgHookCmd, commitMsgHookCmd)
+}
+
+// hooksDir returns the directory git runs hooks from, honouring core.hooksPath.
+func hooksDir() (string, error) {
+	output, err := execCommand("git", "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", withExitCode(exitGitFailure, fmt.Errorf("not a git repository: %w", err))
+	}
+	return filepath.Abs(strings.TrimSpace(string(output)))
+}
+
+// hookScript returns the script installed for hook. It stays a single exec
+// line so the real logic can change without reinstalling.
+func hookScript(hook string) string {
+	executable := "gitter"
+	if path, err := os.Executable(); err == nil {
+		executable = path
+	}
+	return fmt.Sprintf("#!/bin/sh\n%s Run 'gitter hooks uninstall' to remove it.\nexec %s hook %s \"$@\"\n",
+		hookMarker, shellQuote(executable), hook)
+}
+
+// shellQuote quotes s for use as a single word in a POSIX shell script.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// isGitterHook reports whether the file at path was written by gitter.
+func isGitterHook(path string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && strings.Contains(string(data), hookMarker)
+}
+
+func handleHooksInstall(cmd *cobra.Command, args []string) error {
+	dir, err := hooksDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("could not create hooks directory: %w", err)
+	}
+
+	for _, hook := range managedHooks {
+		path := filepath.Join(dir, hook)
+		if _, err := os.Stat(path); err == nil && !isGitterHook(path) {
+			backup := path + backupSuffix
+			if _, err := os.Stat(backup); err == nil {
+				return fmt.Errorf("cannot install %s: both %s and %s already exist", hook, path, backup)
+			}
+			if err := os.Rename(path, backup); err != nil {
+				return fmt.Errorf("could not back up existing %s hook: %w", hook, err)
+			}
+			fmt.Printf("Existing %s hook kept as %s and will still run.\n", hook, backup)
+		}
+		if err := os.WriteFile(path, []byte(hookScript(hook)), 0755); err != nil {
+			return fmt.Errorf("could not write %s hook: %w", hook, err)
+		}
+		fmt.Printf("Installed %s\n", path)
+	}
+	return nil
+}
+
+func handleHooksUninstall(cmd *cobra.Command, args []string) error {
+	dir, err := hooksDir()
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range managedHooks {
+		path := filepath.Join(dir, hook)
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if !isGitterHook(path) {
+			fmt.Printf("Leaving %s alone, it was not installed by gitter.\n", path)
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("could not remove %s hook: %w", hook, err)
+		}
+		fmt.Printf("Removed %s\n", path)
+
+		backup := path + backupSuffix
+		if _, err := os.Stat(backup); err == nil {
+			if err := os.Rename(backup, path); err != nil {
+				return fmt.Errorf("could not restore previous %s hook: %w", hook, err)
+			}
+			fmt.Printf("Restored previous %s hook.\n", hook)
+		}
+	}
+	return nil
+}
+
+// runPreviousHook runs the hook gitter replaced, if there is one, so
+// installing gitter's hooks never disables existing checks.
+func runPreviousHook(hook string, args []string) error {
+	dir, err := hooksDir()
+	if err != nil {
+		return err
+	}
+	backup := filepath.Join(dir, hook+backupSuffix)
+	info, err := os.Stat(backup)
+	if err != nil || info.Mode()&0111 == 0 {
+		return nil
+	}
+
+	previous := execCommand(backup, args...)
+	previous.Stdin = os.Stdin
+	previous.Stdout = os.Stdout
+	previous.Stderr = os.Stderr
+	if err := previous.Run(); err != nil {
+		return withExitCode(exitError, fmt.Errorf("%s hook failed: %w", backup, err))
+	}
+	return nil
+}
+
+// commentChar returns the character git starts comment lines with in
+// message, from core.commentChar. With "auto" git picks one per message, so
+// it is read from the scissors line if there is one.
+func commentChar(message string) string {
+	output, err := execCommand("git", "config", "--get", "core.commentChar").Output()
+	char := strings.TrimSpace(string(output))
+	switch {
+	case err != nil || char == "":
+		return "#"
+	case char == "auto":
+		for _, line := range strings.Split(message, "\n") {
+			if prefix, ok := strings.CutSuffix(line, scissorsLine); ok && prefix != "" {
+				return prefix
+			}
+		}
+		return "#"
+	}
+	return char
+}
+
+// handlePrepareCommitMsgHook fills in the message for a plain 'git commit'.
+// Messages given with -m, -F, a template, or for merges, squashes and
+// amends are left alone. Failures only warn, so the hook never blocks a
+// commit.
+func handlePrepareCommitMsgHook(cmd *cobra.Command, args []string) error {
+	if err := runPreviousHook("prepare-commit-msg", args); err != nil {
+		return err
+	}
+
+	file := args[0]
+	if len(args) > 1 && args[1] != "" {
+		return nil
+	}
+
+	existing, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: gitter could not read the commit message file: %v\n", err)
+		return nil
+	}
+	if cleanMessage(string(existing), commentChar(string(existing))) != "" {
+		return nil
+	}
+
+	// During 'git commit -a' or 'git commit <paths>' git points
+	// GIT_INDEX_FILE at a temporary index, so this is what will be committed.
+	diffOutputBytes, err := execCommand("git", "diff", "--staged").Output()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: gitter could not get the staged diff: %v\n", err)
+		return nil
+	}
+	diffOutput := string(diffOutputBytes)
+	if strings.TrimSpace(diffOutput) == "" {
+		return nil
+	}
+
+	files, err := diff.Parse(diffOutput)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not parse diff, file details will be missing: %v\n", err)
+	}
+
+	message := generateCommitMessage(createDefaultCommitMessage(), diffOutput, files)
+	if _, err := conventional.Parse(message); err != nil {
+		// The template generator does not pick a type; give it one so the
+		// commit-msg hook accepts the message if it is saved unchanged.
+		message = "chore: " + message
+	}
+	message = strings.TrimRight(message, "\n") + "\n" + string(existing)
+	if err := os.WriteFile(file, []byte(message), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: gitter could not write the commit message file: %v\n", err)
+	}
+	return nil
+}
+
+// handleCommitMsgHook rejects commit messages that break Conventional
+// Commits. An empty message is left for git to reject.
+func handleCommitMsgHook(cmd *cobra.Command, args []string) error {
+	if err := runPreviousHook("commit-msg", args); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("could not read the commit message file: %w", err)
+	}
+	message := cleanMessage(string(data), commentChar(string(data)))
+	if message == "" {
+		return nil
+	}
+
+	if violations := conventional.Lint(message, conventional.DefaultRules()); len(violations) > 0 {
+		fmt.Fprintf(os.Stderr, "Commit message does not follow Conventional Commits:\n%s", formatViolations(violations))
+		fmt.Fprintln(os.Stderr, "Fix the message, or use 'git commit --no-verify' to skip this check.")
+		return withExitCode(exitError, nil)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"/usr/local/bin/gitter":    "'/usr/local/bin/gitter'",
+		"/home/me/my tools/gitter": "'/home/me/my tools/gitter'",
+		"/tmp/it's/gitter":         `'/tmp/it'\''s/gitter'`,
+	}
+	for input, want := range tests {
+		if got := shellQuote(input); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
+
+func TestHookScript(t *testing.T) {
+	script := hookScript("commit-msg")
+	if !strings.HasPrefix(script, "#!/bin/sh\n") {
+		t.Errorf("hook script should start with a shebang, got:\n%s", script)
+	}
+	if !strings.Contains(script, hookMarker) {
+		t.Errorf("hook script should contain the gitter marker, got:\n%s", script)
+	}
+	lines := strings.Split(strings.TrimSpace(script), "\n")
+	last := lines[len(lines)-1]
+	if !strings.HasPrefix(last, "exec '") || !strings.HasSuffix(last, `' hook commit-msg "$@"`) {
+		t.Errorf("hook script should exec gitter's hook command, got %q", last)
+	}
+}
+
+// writeHook writes an executable shell hook with the given body.
+func writeHook(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHooksInstallChainsExistingHook(t *testing.T) {
+	dir := inTestRepo(t)
+	hooks := filepath.Join(dir, ".git", "hooks")
+	existing := filepath.Join(hooks, "commit-msg")
+	writeHook(t, existing, "echo previous > \"$(dirname \"$1\")/previous-ran\"")
+
+	captureStdout(func() {
+		if err := handleHooksInstall(nil, nil); err != nil {
+			t.Fatalf("install: %v", err)
+		}
+	})
+	if !isGitterHook(existing) {
+		t.Error("commit-msg should be gitter's hook after install")
+	}
+	if !isGitterHook(filepath.Join(hooks, "prepare-commit-msg")) {
+		t.Error("prepare-commit-msg should be gitter's hook after install")
+	}
+	if _, err := os.Stat(existing + backupSuffix); err != nil {
+		t.Fatalf("existing hook should be kept as %s: %v", backupSuffix, err)
+	}
+
+	msgFile := filepath.Join(dir, "MSG")
+	writeFile(t, msgFile, "feat: add menu\n")
+	if err := handleCommitMsgHook(nil, []string{msgFile}); err != nil {
+		t.Fatalf("commit-msg hook: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "previous-ran")); err != nil {
+		t.Error("the previous commit-msg hook should run before gitter's check")
+	}
+
+	captureStdout(func() {
+		if err := handleHooksInstall(nil, nil); err != nil {
+			t.Fatalf("reinstall: %v", err)
+		}
+	})
+	captureStdout(func() {
+		if err := handleHooksUninstall(nil, nil); err != nil {
+			t.Fatalf("uninstall: %v", err)
+		}
+	})
+	if isGitterHook(existing) {
+		t.Error("commit-msg should no longer be gitter's hook after uninstall")
+	}
+	data, err := os.ReadFile(existing)
+	if err != nil || !strings.Contains(string(data), "previous-ran") {
+		t.Errorf("previous commit-msg hook should be restored, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(existing + backupSuffix); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("backup should be gone after uninstall, stat err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(hooks, "prepare-commit-msg")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("prepare-commit-msg should be removed, stat err = %v", err)
+	}
+}
+
+func TestHooksFailingPreviousHookBlocks(t *testing.T) {
+	dir := inTestRepo(t)
+	writeHook(t, filepath.Join(dir, ".git", "hooks", "commit-msg"+backupSuffix), "exit 1")
+
+	msgFile := filepath.Join(dir, "MSG")
+	writeFile(t, msgFile, "feat: add menu\n")
+	if code := exitCodeOf(handleCommitMsgHook(nil, []string{msgFile})); code != exitError {
+		t.Errorf("exit code = %d, want %d when the previous hook fails", code, exitError)
+	}
+}
+
+func TestHooksInstallHonoursHooksPath(t *testing.T) {
+	dir := inTestRepo(t)
+	git(t, "config", "core.hooksPath", "githooks")
+
+	captureStdout(func() {
+		if err := handleHooksInstall(nil, nil); err != nil {
+			t.Fatalf("install: %v", err)
+		}
+	})
+	for _, hook := range managedHooks {
+		if !isGitterHook(filepath.Join(dir, "githooks", hook)) {
+			t.Errorf("%s should be installed in core.hooksPath", hook)
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git", "hooks", hook)); err == nil {
+			t.Errorf("%s should not be installed in .git/hooks", hook)
+		}
+	}
+}
+
+func TestCommitMsgHook(t *testing.T) {
+	tests := []struct {
+		name        string
+		commentChar string
+		message     string
+		wantCode    int
+	}{
+		{
+			name:    "conventional message",
+			message: "feat: add menu\n# Please enter the commit message\n",
+		},
+		{
+			name:     "not conventional",
+			message:  "added the menu\n",
+			wantCode: exitError,
+		},
+		{
+			name:    "only comments",
+			message: "# Please enter the commit message\n",
+		},
+		{
+			name: "verbose diff below the scissors line",
+			message: "feat: add menu\n# ------------------------ >8 ------------------------\n" +
+				"diff --git a/menu.go b/menu.go\n+" + strings.Repeat("word ", 30) + "\n",
+		},
+		{
+			name:        "custom comment char",
+			commentChar: ";",
+			message:     "feat: add menu\n; Please enter the commit message\n; a comment line that is far too long to be a valid body line in any sensible commit message\n",
+		},
+		{
+			name:        "auto comment char",
+			commentChar: "auto",
+			message:     "feat: add menu\n; ------------------------ >8 ------------------------\nadded the menu\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := inTestRepo(t)
+			if tt.commentChar != "" {
+				git(t, "config", "core.commentChar", tt.commentChar)
+			}
+			msgFile := filepath.Join(dir, "MSG")
+			writeFile(t, msgFile, tt.message)
+
+			var err error
+			captureStderr(func() {
+				err = handleCommitMsgHook(nil, []string{msgFile})
+			})
+			if code := exitCodeOf(err); code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d (err %v)", code, tt.wantCode, err)
+			}
+		})
+	}
+}
+
+func TestPrepareCommitMsgHookFillsVerboseMessage(t *testing.T) {
+	dir := inTestRepo(t)
+	useFakeLLM(t, "feat: add menu")
+	writeFile(t, filepath.Join(dir, "menu.go"), "package menu\n")
+	git(t, "add", "menu.go")
+
+	msgFile := filepath.Join(dir, "MSG")
+	template := "\n# Please enter the commit message\n# ------------------------ >8 ------------------------\ndiff --git a/menu.go b/menu.go\n"
+	writeFile(t, msgFile, template)
+
+	captureStdout(func() {
+		if err := handlePrepareCommitMsgHook(nil, []string{msgFile}); err != nil {
+			t.Fatalf("prepare-commit-msg hook: %v", err)
+		}
+	})
+	data, err := os.ReadFile(msgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "feat: add menu\n") {
+		t.Errorf("message should be pre-filled under git commit -v, got %q", data)
+	}
+	if !strings.HasSuffix(string(data), template) {
+		t.Errorf("git's template should be kept below the message, got %q", data)
+	}
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/biswajitpain/gitter/internal/conventional"
+	"github.com/spf13/cobra"
+)
+
+var lintFile string
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint [<rev-range>...]",
+	Short: "Check that commit messages follow Conventional Commits",
+	Long: `Check commit messages against the Conventional Commits format: a known
+type, an optional scope, an optional "!" breaking marker, a short header
+followed by a blank line, a wrapped body and a well-formed
+"BREAKING CHANGE:" footer.
+
+The arguments are passed to 'git log', so any revision range works.
+Without arguments, the commits not yet pushed to the upstream branch are
+checked, or only HEAD when there is no upstream. Merge commits and
+fixup!/squash! commits are skipped.
+
+Exits with status 1 if any message breaks the rules, so it can gate CI.`,
+	Example: `  gitter lint
+  gitter lint origin/main..HEAD
+  gitter lint --file .git/COMMIT_EDITMSG`,
+	RunE: handleLintCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintCmd.Flags().StringVarP(&lintFile, "file", "F", "", "Check the message in this file instead of commits ('-' reads stdin)")
+}
+
+func handleLintCommand(cmd *cobra.Command, args []string) error {
+	rules := conventional.DefaultRules()
+
+	if lintFile != "" {
+		if len(args) > 0 {
+			return fmt.Errorf("--file cannot be combined with a revision range")
+		}
+		message, err := readMessageFile(lintFile)
+		if err != nil {
+			return err
+		}
+		name := lintFile
+		if name == "-" {
+			name = "Message"
+		}
+		if violations := conventional.Lint(stripCommentLines(message), rules); len(violations) > 0 {
+			fmt.Printf("%s does not follow Conventional Commits:\n%s", name, formatViolations(violations))
+			return withExitCode(exitError, nil)
+		}
+		fmt.Printf("%s follows Conventional Commits.\n", name)
+		return nil
+	}
+
+	if len(args) == 0 {
+		args = defaultLintRange()
+	}
+
+	commits, err := logMessages(args...)
+	if err != nil {
+		return withExitCode(exitGitFailure, err)
+	}
+
+	failed := 0
+	for _, commit := range commits {
+		violations := conventional.Lint(commit.message, rules)
+		if len(violations) == 0 {
+			continue
+		}
+		failed++
+		subject, _, _ := strings.Cut(commit.message, "\n")
+		fmt.Printf("%s %s\n%s", commit.hash[:min(len(commit.hash), 12)], subject, formatViolations(violations))
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n%d of %d commit(s) do not follow Conventional Commits.\n", failed, len(commits))
+		return withExitCode(exitError, nil)
+	}
+	fmt.Printf("All %d commit(s) follow Conventional Commits.\n", len(commits))
+	return nil
+}
+
+// defaultLintRange returns the commits on the current branch that are not
+// on its upstream yet, or just HEAD when no upstream is configured.
+func defaultLintRange() []string {
+	if err := execCommand("git", "rev-parse", "--verify", "--quiet", "@{upstream}").Run(); err == nil {
+		return []string{"@{upstream}..HEAD"}
+	}
+	return []string{"-1", "HEAD"}
+}
+
+// loggedCommit is a commit hash and its full message.
+type loggedCommit struct {
+	hash    string
+	message string
+}
+
+// logMessages returns the hash and raw message of every non-merge commit
+// selected by the given git log arguments, newest first.
+func logMessages(args ...string) ([]loggedCommit, error) {
+	gitArgs := append([]string{"log", "--no-merges", "--format=%H%x00%B%x1e"}, args...)
+	output, err := execCommand("git", gitArgs...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error reading commit log: %w", err)
+	}
+
+	var commits []loggedCommit
+	for _, record := range strings.Split(string(output), "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		hash, message, ok := strings.Cut(record, "\x00")
+		if !ok {
+			continue
+		}
+		commits = append(commits, loggedCommit{hash: hash, message: strings.TrimSpace(message)})
+	}
+	return commits, nil
+}
+
+// readMessageFile reads a commit message from path, or from stdin for "-".
+func readMessageFile(path string) (string, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("could not read message from stdin: %w", err)
+		}
+		return string(data), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read message file: %w", err)
+	}
+	return string(data), nil
+}
+
+// formatViolations renders violations as an indented list, one per line.
+func formatViolations(violations []conventional.Violation) string {
+	var b strings.Builder
+	for _, v := range violations {
+		b.WriteString("  - " + v.String() + "\n")
+	}
+	return b.String()
+}
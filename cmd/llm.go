@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/biswajitpain/gitter/internal/budget"
+	"github.com/biswajitpain/gitter/internal/config"
+	"github.com/biswajitpain/gitter/internal/diff"
+	"github.com/biswajitpain/gitter/internal/llm"
+	"github.com/biswajitpain/gitter/internal/pathpolicy"
+)
+
+// prepareDiffForLLM fits diffOutput into the configured token budget before
+// it is sent to client. Files withheld by the path policy are replaced by a
+// stat line, oversized files are summarised separately, and the user is
+// told on out which files did not go into the prompt verbatim.
+func prepareDiffForLLM(ctx context.Context, out io.Writer, client llm.LLMClient, cfg config.Config, diffOutput string) string {
+	var withheld []*diff.File
+	policy, err := loadPathPolicy(cfg)
+	if err == nil {
+		diffOutput, withheld, err = policy.Filter(diffOutput)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; the diff is not sent to the LLM.\n", err)
+		return "(The diff is withheld because it could not be checked against the path policy.)\n"
+	}
+	reportWithheld(out, withheld)
+
+	summarize := func(ctx context.Context, path string, fileDiff string) (string, error) {
+		ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+		defer cancel()
+		return llm.SummarizeFileDiff(ctx, client, path, fileDiff)
+	}
+
+	result := budget.Prepare(ctx, diffOutput, budget.Options{MaxTokens: cfg.MaxDiffTokens}, summarize)
+	if len(result.Abbreviated) > 0 {
+		fmt.Fprintf(out, "Abbreviated generated, vendored or lockfile changes: %s\n", strings.Join(result.Abbreviated, ", "))
+	}
+	if len(result.Summarized) > 0 {
+		fmt.Fprintf(out, "Diff too large for one prompt, summarised separately: %s\n", strings.Join(result.Summarized, ", "))
+	}
+	if len(result.Omitted) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: left out of the prompt: %s\n", strings.Join(result.Omitted, ", "))
+	}
+	return result.Diff + pathpolicy.Note(withheld)
+}
+
+// reportWithheld tells the user on out which files the path policy kept
+// out of the prompt.
+func reportWithheld(out io.Writer, withheld []*diff.File) {
+	if len(withheld) == 0 {
+		return
+	}
+	paths := make([]string, len(withheld))
+	for i, file := range withheld {
+		paths[i] = file.Path()
+	}
+	fmt.Fprintf(out, "Withheld from the LLM by path policy: %s\n", strings.Join(paths, ", "))
+}
+
+// loadPathPolicy returns the policy deciding which files may be sent to the
+// LLM, from the repository's .gitterignore and the configured globs.
+func loadPathPolicy(cfg config.Config) (*pathpolicy.Policy, error) {
+	root := "."
+	if top, err := execCommand("git", "rev-parse", "--show-toplevel").Output(); err == nil {
+		root = strings.TrimSpace(string(top))
+	}
+	return pathpolicy.Load(root, cfg.LLMInclude, cfg.LLMExclude)
+}
+
+// streamCommitMessage generates a commit message with client, printing the
+// tokens to stdout as they arrive so the user can watch it being written.
+func streamCommitMessage(ctx context.Context, client llm.LLMClient, provider string, diff string, userMessage string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	fmt.Printf("Generating commit message with %s (press Ctrl-C to cancel)...\n\n", provider)
+	message, err := client.StreamCommitMessage(ctx, diff, userMessage, func(token string) {
+		fmt.Print(token)
+	})
+	fmt.Println()
+	return message, err
+}
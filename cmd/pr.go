@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/biswajitpain/gitter/internal/config"
+	"github.com/biswajitpain/gitter/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prBase   string
+	prOutput string
+	prNoLLM  bool
+)
+
+// prCmd represents the pr command
+var prCmd = &cobra.Command{
+	Use:   "pr",
+	Short: "Draft a pull request title and description for the current branch",
+	Long: `Draft a pull request for the commits on the current branch that are not
+on the target branch. The commit log and the combined diff since the
+merge-base are sent to the configured LLM, which writes a title and a
+Markdown description with summary, testing and risks sections.
+
+Without an LLM, or with --no-llm, a template built from the commit
+subjects is used instead. The draft is printed to stdout, or written to
+the file given with --output; progress messages go to stderr.`,
+	Example: `  gitter pr
+  gitter pr --base develop
+  gitter pr --output pr.md`,
+	Args: cobra.NoArgs,
+	RunE: handlePrCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(prCmd)
+
+	prCmd.Flags().StringVarP(&prBase, "base", "b", "", "Branch the pull request targets (default: the remote's default branch, main or master)")
+	prCmd.Flags().StringVarP(&prOutput, "output", "o", "", "Write the draft to this file instead of stdout")
+	prCmd.Flags().BoolVar(&prNoLLM, "no-llm", false, "Use the commit subject template even if an LLM is configured")
+}
+
+func handlePrCommand(cmd *cobra.Command, args []string) error {
+	if err := execCommand("git", "rev-parse", "--is-inside-work-tree").Run(); err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("not a git repository: %w", err))
+	}
+
+	base := prBase
+	if base == "" {
+		var err error
+		if base, err = defaultBaseBranch(); err != nil {
+			return err
+		}
+	}
+
+	mergeBaseBytes, err := execCommand("git", "merge-base", base, "HEAD").Output()
+	if err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("could not find the merge-base with %s: %w", base, err))
+	}
+	mergeBase := strings.TrimSpace(string(mergeBaseBytes))
+
+	commits, err := logMessages("--reverse", mergeBase+"..HEAD")
+	if err != nil {
+		return withExitCode(exitGitFailure, err)
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("HEAD has no commits that are not already on %s", base)
+	}
+
+	diffOutputBytes, err := execCommand("git", "diff", mergeBase, "HEAD").Output()
+	if err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("error getting diff: %w", err))
+	}
+
+	fmt.Fprintf(os.Stderr, "Drafting pull request for %d commit(s) since %s.\n", len(commits), base)
+	draft := ""
+	if !prNoLLM {
+		draft = draftPullRequest(commits, string(diffOutputBytes))
+	}
+	if draft == "" {
+		branch, _ := execCommand("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+		draft = generatePullRequestTemplate(strings.TrimSpace(string(branch)), commits)
+	}
+
+	if prOutput != "" {
+		if err := os.WriteFile(prOutput, []byte(draft), 0644); err != nil {
+			return fmt.Errorf("could not write %s: %w", prOutput, err)
+		}
+		fmt.Fprintf(os.Stderr, "Pull request draft written to %s\n", prOutput)
+		return nil
+	}
+	fmt.Print(draft)
+	return nil
+}
+
+// defaultBaseBranch guesses the branch pull requests target: the remote's
+// default branch if origin/HEAD is set, otherwise main or master.
+func defaultBaseBranch() (string, error) {
+	if output, err := execCommand("git", "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD").Output(); err == nil {
+		return strings.TrimSpace(string(output)), nil
+	}
+	for _, candidate := range []string{"origin/main", "origin/master", "main", "master"} {
+		if err := execCommand("git", "rev-parse", "--verify", "--quiet", candidate).Run(); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", errors.New("could not determine the target branch, use --base to choose one")
+}
+
+// draftPullRequest asks the configured LLM for a pull request draft. It
+// returns an empty string, after telling the user why, if no LLM is
+// configured or the request fails.
+func draftPullRequest(commits []loggedCommit, diffOutput string) string {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load config, using template: %v\n", err)
+	}
+	llmClient, err := newLLMClientFunc(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "No LLM configured (%v), using template.\n", err)
+		return ""
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	promptDiff := prepareDiffForLLM(ctx, os.Stderr, llmClient, cfg, diffOutput)
+
+	var log strings.Builder
+	for _, commit := range commits {
+		fmt.Fprintf(&log, "commit %s\n%s\n\n", commit.hash, commit.message)
+	}
+
+	fmt.Fprintf(os.Stderr, "Generating pull request with %s (press Ctrl-C to cancel)...\n", cfg.Provider)
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	draft, err := llm.DraftPullRequest(ctx, llmClient, log.String(), promptDiff)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		fmt.Fprintln(os.Stderr, "LLM request cancelled, using template.")
+		return ""
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: LLM request failed, using template: %v\n", err)
+		return ""
+	}
+
+	title, description, _ := strings.Cut(strings.TrimSpace(draft), "\n")
+	title = strings.TrimSpace(strings.TrimLeft(title, "# "))
+	return fmt.Sprintf("# %s\n\n%s\n", title, strings.TrimSpace(description))
+}
+
+// generatePullRequestTemplate builds a pull request draft from the commit
+// subjects alone. A single commit lends its subject and body; several
+// commits are listed under a title taken from the branch name.
+func generatePullRequestTemplate(branch string, commits []loggedCommit) string {
+	var b strings.Builder
+	subject, body, _ := strings.Cut(commits[0].message, "\n")
+
+	if len(commits) == 1 {
+		fmt.Fprintf(&b, "# %s\n\n## Summary\n\n", subject)
+		if body = strings.TrimSpace(body); body != "" {
+			b.WriteString(body + "\n")
+		} else {
+			b.WriteString(subject + "\n")
+		}
+	} else {
+		title := branch
+		if title == "" || title == "HEAD" {
+			title = subject
+		}
+		fmt.Fprintf(&b, "# %s\n\n## Summary\n\n", title)
+		for _, commit := range commits {
+			subject, _, _ := strings.Cut(commit.message, "\n")
+			fmt.Fprintf(&b, "- %s (%s)\n", subject, commit.hash[:min(len(commit.hash), 7)])
+		}
+	}
+
+	b.WriteString("\n## Testing\n\nDescribe how the change was tested.\n")
+	b.WriteString("\n## Risks\n\nNone identified.\n")
+	return b.String()
+}
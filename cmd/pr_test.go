@@ -0,0 +1,39 @@
+package cmd
+
+import "testing"
+
+func TestGeneratePullRequestTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		branch  string
+		commits []loggedCommit
+		want    string
+	}{
+		{
+			name:    "single commit uses its subject and body",
+			branch:  "feature/lint",
+			commits: []loggedCommit{{hash: "1111111aaaa", message: "feat: add lint command\n\nChecks commit messages."}},
+			want: "# feat: add lint command\n\n## Summary\n\nChecks commit messages.\n" +
+				"\n## Testing\n\nDescribe how the change was tested.\n" +
+				"\n## Risks\n\nNone identified.\n",
+		},
+		{
+			name:   "several commits are listed under the branch name",
+			branch: "feature/lint",
+			commits: []loggedCommit{
+				{hash: "1111111aaaa", message: "feat: add linter"},
+				{hash: "2222222bbbb", message: "docs: describe linter\n\nMore detail."},
+			},
+			want: "# feature/lint\n\n## Summary\n\n- feat: add linter (1111111)\n- docs: describe linter (2222222)\n" +
+				"\n## Testing\n\nDescribe how the change was tested.\n" +
+				"\n## Risks\n\nNone identified.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := generatePullRequestTemplate(tt.branch, tt.commits); got != tt.want {
+				t.Errorf("generatePullRequestTemplate() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
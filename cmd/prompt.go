@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// errNotInteractive is returned when a command needs an answer from the
+// user but stdin is not a terminal, e.g. in CI or when input is piped.
+var errNotInteractive = errors.New("stdin is not a terminal and an answer is required")
+
+// stdinIsTerminal reports whether stdin is attached to a terminal.
+// It is a package-level variable to allow simulating a terminal in tests.
+var stdinIsTerminal = func() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// prompter asks the user questions on stdout and reads answers from stdin.
+// It fails fast with errNotInteractive instead of waiting on a pipe that
+// nobody will ever answer.
+type prompter struct {
+	reader *bufio.Reader
+}
+
+// newPrompter returns a prompter reading from os.Stdin.
+func newPrompter() *prompter {
+	return &prompter{reader: bufio.NewReader(os.Stdin)}
+}
+
+// ask prints question and returns the trimmed answer.
+func (p *prompter) ask(question string) (string, error) {
+	if !stdinIsTerminal() {
+		return "", errNotInteractive
+	}
+	fmt.Print(question)
+	answer, err := p.reader.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if err != nil && answer == "" {
+		// Stdin was closed (e.g. Ctrl-D); there is no answer to give.
+		fmt.Println()
+		return "", errNotInteractive
+	}
+	return answer, nil
+}
+
+// confirm asks a yes/no question and reports whether the answer was yes.
+func (p *prompter) confirm(question string) (bool, error) {
+	answer, err := p.ask(question + " (y/n): ")
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
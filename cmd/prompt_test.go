@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPrompter(t *testing.T) {
+	oldIsTerminal := stdinIsTerminal
+	defer func() { stdinIsTerminal = oldIsTerminal }()
+
+	stdinIsTerminal = func() bool { return true }
+	p := &prompter{reader: bufio.NewReader(strings.NewReader("  Yes \nsome answer\n"))}
+
+	var ok bool
+	var answer string
+	var err error
+	captureStdout(func() {
+		ok, err = p.confirm("Continue?")
+	})
+	if err != nil || !ok {
+		t.Errorf("confirm() = (%v, %v), want (true, nil)", ok, err)
+	}
+	captureStdout(func() {
+		answer, err = p.ask("> ")
+	})
+	if err != nil || answer != "some answer" {
+		t.Errorf("ask() = (%q, %v), want (\"some answer\", nil)", answer, err)
+	}
+
+	// Once input is exhausted there is nobody left to answer.
+	captureStdout(func() {
+		_, err = p.ask("> ")
+	})
+	if !errors.Is(err, errNotInteractive) {
+		t.Errorf("ask() at EOF returned %v, want errNotInteractive", err)
+	}
+
+	// Without a terminal, questions fail immediately.
+	stdinIsTerminal = func() bool { return false }
+	p = &prompter{reader: bufio.NewReader(strings.NewReader("y\n"))}
+	if _, err := p.confirm("Continue?"); !errors.Is(err, errNotInteractive) {
+		t.Errorf("confirm() without a terminal returned %v, want errNotInteractive", err)
+	}
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/biswajitpain/gitter/internal/changelog"
+	"github.com/biswajitpain/gitter/internal/semver"
+	"github.com/spf13/cobra"
+)
+
+var (
+	releaseDryRun bool
+	releasePre    string
+	releaseBump   string
+)
+
+// releaseCmd represents the release command
+var releaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Compute the next semantic version and create an annotated tag",
+	Long: `Look at the commits since the last semver release tag, compute the next
+version and create an annotated tag for HEAD whose message is the
+changelog for the release.
+
+The bump follows Conventional Commits:
+  major  any breaking change ("feat!:" or a BREAKING CHANGE footer)
+  minor  any "feat" commit
+  patch  any "fix" or "perf" commit
+
+Other commit types do not trigger a release on their own; use --bump to
+release anyway. With --pre, a pre-release such as v1.3.0-rc.1 is tagged,
+numbered after any existing ones. The tag is created locally only, and
+the command refuses to run with uncommitted changes to tracked files.`,
+	Example: `  gitter release --dry-run
+  gitter release
+  gitter release --pre rc
+  gitter release --bump patch`,
+	Args: cobra.NoArgs,
+	RunE: handleReleaseCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(releaseCmd)
+
+	releaseCmd.Flags().BoolVar(&releaseDryRun, "dry-run", false, "Print the next version, the reasoning and the tag message without tagging")
+	releaseCmd.Flags().StringVar(&releasePre, "pre", "", "Tag a pre-release with this identifier, e.g. rc, beta")
+	releaseCmd.Flags().StringVar(&releaseBump, "bump", "", "Force the bump: major, minor or patch")
+}
+
+func handleReleaseCommand(cmd *cobra.Command, args []string) error {
+	if err := execCommand("git", "rev-parse", "--is-inside-work-tree").Run(); err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("not a git repository: %w", err))
+	}
+
+	forced := semver.None
+	if releaseBump != "" {
+		var err error
+		if forced, err = semver.ParseBump(releaseBump); err != nil {
+			return err
+		}
+	}
+
+	status, err := execCommand("git", "status", "--porcelain", "--untracked-files=no").Output()
+	if err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("could not check the working tree: %w", err))
+	}
+	if len(strings.TrimSpace(string(status))) > 0 {
+		if !releaseDryRun {
+			return errors.New("the working tree has uncommitted changes; commit or stash them before releasing")
+		}
+		fmt.Println("Note: the working tree has uncommitted changes, a real release would refuse to run.")
+	}
+
+	allTagsOutput, err := execCommand("git", "tag", "--list").Output()
+	if err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("could not list tags: %w", err))
+	}
+	mergedTagsOutput, err := execCommand("git", "tag", "--list", "--merged", "HEAD").Output()
+	if err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("could not list tags: %w", err))
+	}
+	allVersions := semver.FromTags(strings.Split(string(allTagsOutput), "\n"))
+
+	// The last release is the highest stable version reachable from HEAD;
+	// pre-releases are collected into the next release's notes.
+	var last *semver.Tagged
+	for _, tagged := range semver.FromTags(strings.Split(string(mergedTagsOutput), "\n")) {
+		if !tagged.Version.IsPrerelease() {
+			last = &tagged
+			break
+		}
+	}
+
+	from, base := "", semver.Version{Prefix: "v"}
+	if last != nil {
+		from, base = last.Tag, last.Version
+		fmt.Printf("Last release: %s\n", last.Tag)
+	} else {
+		fmt.Println("No release tags found, starting from v0.0.0.")
+	}
+
+	log, err := buildChangelog(from, "HEAD", "")
+	if err != nil {
+		return err
+	}
+	if log.Empty() {
+		if from == "" {
+			return withExitCode(exitNothingToCommit, errors.New("no commits to release"))
+		}
+		return withExitCode(exitNothingToCommit, fmt.Errorf("no commits since %s, nothing to release", from))
+	}
+
+	bump, reasons := decideBump(log)
+	for _, reason := range reasons {
+		fmt.Println("  " + reason)
+	}
+	if forced != semver.None {
+		fmt.Printf("Bump forced to %s with --bump (commits suggest %s).\n", forced, bump)
+		bump = forced
+	}
+	if bump == semver.None {
+		return withExitCode(exitNothingToCommit, errors.New("no breaking, feat, fix or perf commits since the last release; use --bump to release anyway"))
+	}
+
+	next := base.Increment(bump)
+	if releasePre != "" {
+		existing := make([]semver.Version, len(allVersions))
+		for i, tagged := range allVersions {
+			existing[i] = tagged.Version
+		}
+		next = semver.NextPrerelease(next, releasePre, existing)
+	}
+	tag := next.String()
+	for _, tagged := range allVersions {
+		if tagged.Tag == tag {
+			return fmt.Errorf("tag %s already exists", tag)
+		}
+	}
+	fmt.Printf("Next version: %s (%s bump)\n", tag, bump)
+
+	log.Version = tag
+	log.Date = timeNow().Format("2006-01-02")
+	message := log.Markdown()
+
+	if releaseDryRun {
+		fmt.Println("\n--- Tag Message ---")
+		fmt.Print(message)
+		fmt.Println("-------------------")
+		fmt.Println("Dry run: no tag was created.")
+		return nil
+	}
+
+	// verbatim keeps the Markdown headings, which git would otherwise strip
+	// as comments.
+	if err := execCommand("git", "tag", "--annotate", "--cleanup=verbatim", "-m", message, tag).Run(); err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("error creating tag: %w", err))
+	}
+	fmt.Printf("Created tag %s. Push it with: git push origin %s\n", tag, tag)
+	return nil
+}
+
+// decideBump returns the bump the changelog's commits call for, with one
+// line of reasoning per commit that contributes to it.
+func decideBump(log *changelog.Changelog) (semver.Bump, []string) {
+	bump := semver.None
+	var reasons []string
+	for _, group := range log.Groups {
+		for _, entry := range group.Entries {
+			entryBump, why := semver.None, ""
+			switch {
+			case entry.Breaking:
+				entryBump, why = semver.Major, "breaking change"
+			case entry.Type == "feat":
+				entryBump, why = semver.Minor, "new feature"
+			case entry.Type == "fix" || entry.Type == "perf":
+				entryBump, why = semver.Patch, entry.Type
+			default:
+				continue
+			}
+			reasons = append(reasons, fmt.Sprintf("%s %s: %s -> %s", entry.ShortHash(), why, entry.Description, entryBump))
+			if entryBump > bump {
+				bump = entryBump
+			}
+		}
+	}
+	return bump, reasons
+}
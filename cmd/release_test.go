@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/biswajitpain/gitter/internal/changelog"
+	"github.com/biswajitpain/gitter/internal/semver"
+)
+
+func TestDecideBump(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+		want     semver.Bump
+		reasons  int
+	}{
+		{"chores only", []string{"chore: tidy", "docs: typo"}, semver.None, 0},
+		{"fix", []string{"fix: crash", "chore: tidy"}, semver.Patch, 1},
+		{"perf", []string{"perf: faster diff"}, semver.Patch, 1},
+		{"feat beats fix", []string{"fix: crash", "feat: new flag"}, semver.Minor, 2},
+		{"breaking marker", []string{"feat: new flag", "refactor!: drop old config"}, semver.Major, 2},
+		{"breaking footer", []string{"fix: x\n\nBREAKING CHANGE: y"}, semver.Major, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commits []changelog.Commit
+			for i, message := range tt.messages {
+				commits = append(commits, changelog.Commit{Hash: string(rune('a'+i)) + "000000", Message: message})
+			}
+			bump, reasons := decideBump(changelog.Build(commits, changelog.Options{}))
+			if bump != tt.want {
+				t.Errorf("decideBump() = %s, want %s", bump, tt.want)
+			}
+			if len(reasons) != tt.reasons {
+				t.Errorf("decideBump() gave %d reasons, want %d: %v", len(reasons), tt.reasons, reasons)
+			}
+		})
+	}
+}
+
+// resetReleaseFlags clears the release flags for a test and restores them
+// afterwards.
+func resetReleaseFlags(t *testing.T) {
+	oldDryRun, oldPre, oldBump := releaseDryRun, releasePre, releaseBump
+	releaseDryRun, releasePre, releaseBump = false, "", ""
+	t.Cleanup(func() { releaseDryRun, releasePre, releaseBump = oldDryRun, oldPre, oldBump })
+}
+
+func TestReleaseRefusesDirtyTree(t *testing.T) {
+	inTestRepo(t)
+	resetReleaseFlags(t)
+	writeFile(t, "menu.go", "package menu\n")
+	commitAll(t, "feat: add menu")
+	writeFile(t, "README.md", "changed\n")
+
+	var err error
+	captureStdout(func() { err = handleReleaseCommand(nil, nil) })
+	if err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+		t.Errorf("release with a dirty work tree: err = %v, want it refused", err)
+	}
+	if tags := git(t, "tag", "--list"); tags != "" {
+		t.Errorf("no tag should be created, got %q", tags)
+	}
+}
+
+func TestReleaseTagsNextVersion(t *testing.T) {
+	inTestRepo(t)
+	resetReleaseFlags(t)
+	writeFile(t, "menu.go", "package menu\n")
+	commitAll(t, "feat: add menu")
+
+	var err error
+	captureStdout(func() { err = handleReleaseCommand(nil, nil) })
+	if err != nil {
+		t.Fatalf("first release: %v", err)
+	}
+	if tags := git(t, "tag", "--list"); tags != "v0.1.0" {
+		t.Errorf("tags = %q, want v0.1.0", tags)
+	}
+
+	captureStdout(func() { err = handleReleaseCommand(nil, nil) })
+	if code := exitCodeOf(err); code != exitNothingToCommit || !strings.Contains(err.Error(), "no commits since v0.1.0") {
+		t.Errorf("release with nothing new: exit code %d, err %v", code, err)
+	}
+}
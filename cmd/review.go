@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/biswajitpain/gitter/internal/budget"
+	"github.com/biswajitpain/gitter/internal/config"
+	"github.com/biswajitpain/gitter/internal/diff"
+	"github.com/biswajitpain/gitter/internal/llm"
+	"github.com/biswajitpain/gitter/internal/pathpolicy"
+	"github.com/biswajitpain/gitter/internal/review"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reviewStaged bool
+	reviewFormat string
+)
+
+// reviewCmd represents the review command
+var reviewCmd = &cobra.Command{
+	Use:   "review [--staged | <rev-range> | <commit>]",
+	Short: "Ask the configured LLM to review a change",
+	Long: `Send a diff to the configured LLM for a quick code review. The model
+replies with structured findings (file, line, severity, message), which
+are validated and printed grouped by file with file:line references.
+
+Without arguments, all uncommitted changes to tracked files are reviewed.
+With --staged only the staged changes are, a revision range such as
+main..HEAD reviews those commits, and a single commit reviews the changes
+it made.
+
+Formats:
+  text   findings grouped by file (default)
+  json   the validated findings
+  sarif  SARIF 2.1.0, for upload to code scanning tools`,
+	Example: `  gitter review --staged
+  gitter review main..HEAD
+  gitter review HEAD~1
+  gitter review origin/main..HEAD --format sarif > review.sarif`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: handleReviewCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(reviewCmd)
+
+	reviewCmd.Flags().BoolVar(&reviewStaged, "staged", false, "Review the staged changes")
+	reviewCmd.Flags().StringVarP(&reviewFormat, "format", "f", "text", "Output format: text, json or sarif")
+}
+
+func handleReviewCommand(cmd *cobra.Command, args []string) error {
+	switch reviewFormat {
+	case "text", "json", "sarif":
+	default:
+		return fmt.Errorf("unknown format %q, use text, json or sarif", reviewFormat)
+	}
+	if reviewStaged && len(args) > 0 {
+		return errors.New("--staged cannot be combined with a revision range")
+	}
+
+	diffArgs := []string{"diff", "HEAD"}
+	switch {
+	case reviewStaged:
+		diffArgs = []string{"diff", "--staged"}
+	case len(args) == 1:
+		var err error
+		if diffArgs, err = reviewRevisionArgs(args[0]); err != nil {
+			return err
+		}
+	}
+	diffOutputBytes, err := execCommand("git", diffArgs...).Output()
+	if err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("error getting diff: %w", err))
+	}
+	diffOutput := string(diffOutputBytes)
+	if strings.TrimSpace(diffOutput) == "" {
+		return withExitCode(exitNothingToCommit, errors.New("no changes to review"))
+	}
+
+	files, err := diff.Parse(diffOutput)
+	if err != nil {
+		return fmt.Errorf("could not parse diff: %w", err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load config: %v\n", err)
+	}
+	llmClient, err := newLLMClientFunc(cfg)
+	if err != nil {
+		return fmt.Errorf("gitter review needs an LLM, set one up with 'gitter config': %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Files withheld by the path policy are neither shown nor reviewed.
+	policy, err := loadPathPolicy(cfg)
+	if err != nil {
+		return err
+	}
+	files, withheld := policy.Split(files)
+	reportWithheld(os.Stderr, withheld)
+	if len(files) == 0 {
+		return errors.New("every changed file is withheld by the path policy, there is nothing to review")
+	}
+
+	// Numbered lines let the model point at exact lines. If that does not
+	// fit, fall back to the budgeted diff, where references are less precise.
+	promptDiff := review.FormatDiff(files) + pathpolicy.Note(withheld)
+	maxTokens := cfg.MaxDiffTokens
+	if maxTokens <= 0 {
+		maxTokens = budget.DefaultMaxTokens
+	}
+	if budget.EstimateTokens(promptDiff) > maxTokens {
+		promptDiff = prepareDiffForLLM(ctx, os.Stderr, llmClient, cfg, diffOutput)
+	}
+
+	fmt.Fprintf(os.Stderr, "Reviewing %d file(s) with %s (press Ctrl-C to cancel)...\n", len(files), cfg.Provider)
+	findings, err := requestReview(ctx, llmClient, promptDiff, files)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return withExitCode(exitCancelled, errors.New("review cancelled"))
+	}
+	if err != nil {
+		return err
+	}
+
+	switch reviewFormat {
+	case "sarif":
+		data, err := review.SARIF(findings, version)
+		if err != nil {
+			return fmt.Errorf("could not encode SARIF: %w", err)
+		}
+		os.Stdout.Write(data)
+	case "json":
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("could not encode findings: %w", err)
+		}
+		fmt.Println(string(data))
+	default:
+		fmt.Print(review.Text(findings))
+	}
+	return nil
+}
+
+// reviewRevisionArgs returns the git command that shows the changes of rev:
+// the diff of a range such as main..HEAD, or the changes a single commit
+// made. "git diff <commit>" would instead compare the commit with the work
+// tree and mix in uncommitted edits.
+func reviewRevisionArgs(rev string) ([]string, error) {
+	if strings.Contains(rev, "..") || strings.HasSuffix(rev, "^!") {
+		return []string{"diff", rev}, nil
+	}
+	if err := execCommand("git", "rev-parse", "--verify", "--quiet", rev+"^{commit}").Run(); err != nil {
+		return nil, fmt.Errorf("%q is neither a commit nor a revision range such as main..HEAD", rev)
+	}
+	// Merges are reviewed by what they brought into the first parent.
+	return []string{"show", "-m", "--first-parent", "--format=", rev}, nil
+}
+
+// requestReview asks the LLM for findings, giving it one more chance if
+// the first reply does not match the expected schema.
+func requestReview(ctx context.Context, client llm.LLMClient, promptDiff string, files []*diff.File) ([]review.Finding, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Minute)
+	defer cancel()
+
+	feedback := ""
+	for attempt := 1; ; attempt++ {
+		reply, err := llm.ReviewDiff(ctx, client, promptDiff, feedback)
+		if err != nil {
+			return nil, fmt.Errorf("LLM review failed: %w", err)
+		}
+		findings, err := review.Parse(reply, files)
+		if err == nil {
+			return findings, nil
+		}
+		if attempt == 2 {
+			return nil, fmt.Errorf("the LLM did not return valid findings: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v\nRetrying once...\n", err)
+		feedback = err.Error()
+	}
+}
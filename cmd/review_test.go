@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReview_SingleCommitLeavesOutWorkTree(t *testing.T) {
+	inTestRepo(t)
+	fake := useFakeLLM(t, `{"findings": []}`)
+	writeFile(t, "main.go", "package main\n")
+	commitAll(t, "add main")
+	writeFile(t, "main.go", "package main\n\nfunc uncommitted() {}\n")
+	writeFile(t, "README.md", "hello\nmore\n")
+
+	oldFormat := reviewFormat
+	defer func() { reviewFormat = oldFormat }()
+	reviewFormat = "text"
+
+	for _, rev := range []string{"HEAD", "HEAD^!", "HEAD~1..HEAD"} {
+		fake.prompts = nil
+		var err error
+		captureStdout(func() {
+			captureStderr(func() { err = handleReviewCommand(reviewCmd, []string{rev}) })
+		})
+		if err != nil {
+			t.Fatalf("review %s error: %v", rev, err)
+		}
+		prompt := fake.prompts[0]
+		if !strings.Contains(prompt, "main.go") || strings.Contains(prompt, "uncommitted") || strings.Contains(prompt, "README.md") {
+			t.Errorf("review %s sent something other than the commit's changes:\n%s", rev, prompt)
+		}
+	}
+
+	if _, err := reviewRevisionArgs("no-such-branch"); err == nil || !strings.Contains(err.Error(), "neither a commit nor a revision range") {
+		t.Errorf("reviewRevisionArgs() of an unknown revision error = %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -9,7 +10,36 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// Exit codes used by gitter's own commands so scripts can tell outcomes apart.
+const (
+	exitError           = 1 // any other error, including usage errors
+	exitNothingToCommit = 2 // there were no changes to commit
+	exitCancelled       = 3 // the user (or a missing answer) cancelled the operation
+	exitGitFailure      = 4 // a git command failed
+)
+
+// exitCodeError carries a specific exit code out of a command's RunE.
+// A nil err means the command has already explained itself to the user.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
 
+// withExitCode wraps err so that gitter exits with code.
+func withExitCode(code int, err error) error {
+	return &exitCodeError{code: code, err: err}
+}
 
 var rootCmd = &cobra.Command{
 	Use:   "gitter",
@@ -18,16 +48,34 @@ var rootCmd = &cobra.Command{
 with an intelligent "commit review" (cr) command and LLM-powered message generation.`,
 	// Disable Cobra's default "unknown command" error and let our Run function handle it.
 	// This allows us to pass unknown commands directly to git.
-	Run: func(cmd *cobra.Command, args []string) {
-		ExecuteRootCommand(cmd, args, os.Stdout, os.Stderr)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if code := ExecuteRootCommand(cmd, args, os.Stdout, os.Stderr); code != 0 {
+			return withExitCode(code, nil)
+		}
+		return nil
 	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// It returns the process exit code.
 func Execute() int {
+	// Cobra rejects unknown subcommands before Run is reached, so anything
+	// it cannot resolve is handed straight to git.
+	if _, _, err := rootCmd.Find(os.Args[1:]); err != nil {
+		return ExecuteRootCommand(rootCmd, os.Args[1:], os.Stdout, os.Stderr)
+	}
+
 	if err := rootCmd.Execute(); err != nil {
-		return 1
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			if exitErr.err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", exitErr.err)
+			}
+			return exitErr.code
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
 	}
 	return 0
 }
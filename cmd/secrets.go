@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/biswajitpain/gitter/internal/config"
+	"github.com/biswajitpain/gitter/internal/diff"
+	"github.com/biswajitpain/gitter/internal/llm"
+	"github.com/biswajitpain/gitter/internal/redact"
+)
+
+// warnedRedaction records that the user has been told about masked
+// secrets, so a command making several LLM requests warns only once.
+var warnedRedaction bool
+
+// newRedactingLLMClient returns the configured LLM client, wrapped so that
+// likely secrets are masked in everything sent to it and, if enabled, what
+// is sent is recorded in the audit log.
+func newRedactingLLMClient(cfg config.Config) (llm.LLMClient, error) {
+	client, err := llm.NewLLMClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if client, err = newAuditingClient(cfg, client); err != nil {
+		return nil, err
+	}
+	redactor, err := redact.New(cfg.RedactPatterns)
+	if err != nil {
+		return nil, err
+	}
+	return &llm.RedactingClient{Client: client, Redactor: redactor, OnRedact: warnRedacted}, nil
+}
+
+// warnRedacted tells the user which kinds of secrets were masked.
+func warnRedacted(matches []redact.Match) {
+	if warnedRedaction {
+		return
+	}
+	warnedRedaction = true
+	rules := map[string]bool{}
+	for _, m := range matches {
+		rules[m.Rule] = true
+	}
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(os.Stderr, "Warning: masked likely secrets (%s) before sending the changes to the LLM.\n", strings.Join(names, ", "))
+}
+
+// checkStagedSecrets looks for likely secrets added by files, or by the
+// added lines of diffOutput when it could not be parsed and files is nil.
+// If there are any it lists them and, unless --allow-secrets or --dry-run
+// is given, refuses to go on.
+func checkStagedSecrets(diffOutput string, files []*diff.File) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load config: %v\n", err)
+	}
+	redactor, err := redact.New(cfg.RedactPatterns)
+	if err != nil {
+		return err
+	}
+	var findings []redact.Finding
+	if files != nil {
+		findings = redactor.ScanDiff(files)
+	} else {
+		findings = redactor.ScanAddedLines(diffOutput)
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr, "The staged changes appear to contain secrets:")
+	for _, f := range findings {
+		if f.File == "" {
+			fmt.Fprintf(os.Stderr, "  line %d of the diff: %s\n", f.Line, f.Rule)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "  %s:%d: %s\n", f.File, f.Line, f.Rule)
+	}
+	if crAllowSecrets || crDryRun {
+		fmt.Fprintln(os.Stderr, "Warning: continuing anyway; they are masked before anything is sent to the LLM.")
+		return nil
+	}
+	fmt.Fprintln(os.Stderr, "Remove them from the commit, or use --allow-secrets if they are not real secrets.")
+	return withExitCode(exitError, nil)
+}
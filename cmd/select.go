@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/biswajitpain/gitter/internal/diff"
+)
+
+// changeChoice is an unstaged change offered for staging: a tracked file
+// with its diff, or an untracked file.
+type changeChoice struct {
+	path     string
+	file     *diff.File // nil for untracked files
+	selected bool
+	hunks    []*diff.Hunk // the hunks to stage; all of them unless picked one by one
+}
+
+// label describes the change for the file list.
+func (c *changeChoice) label() string {
+	if c.file == nil {
+		return fmt.Sprintf("%s (untracked)", c.path)
+	}
+	if c.file.Binary {
+		return fmt.Sprintf("%s (%s, binary)", c.path, c.file.Change)
+	}
+	return fmt.Sprintf("%s (%s, +%d -%d)", c.path, c.file.Change, c.file.Added(), c.file.Removed())
+}
+
+// hunkwise reports whether the change can be staged one hunk at a time.
+// Whole-file changes such as additions, deletions and binary files cannot.
+func (c *changeChoice) hunkwise() bool {
+	return c.file != nil && c.file.Change == diff.Modified && !c.file.Binary && len(c.file.Hunks) > 0
+}
+
+// selectChanges lets the user choose which unstaged files matching
+// pathspecs, and optionally which hunks within them, to stage, like
+// "git add -p". The chosen hunks are staged with "git apply --cached" and
+// chosen untracked files with "git add". It reports whether anything was
+// staged.
+func selectChanges(prompt *prompter, pathspecs []string) (bool, error) {
+	choices, err := unstagedChanges(pathspecs)
+	if err != nil {
+		return false, err
+	}
+	if len(choices) == 0 {
+		fmt.Println("There are no unstaged changes to select from.")
+		return false, nil
+	}
+
+	// 1. Toggle whole files.
+	for {
+		fmt.Println("\nUnstaged changes:")
+		for i, c := range choices {
+			mark := " "
+			if c.selected {
+				mark = "x"
+			}
+			fmt.Printf("  %2d. [%s] %s\n", i+1, mark, c.label())
+		}
+		answer, err := prompt.ask("Toggle files by number (e.g. \"1 3-5\"), [a]ll, [n]one, Enter to continue, [q]uit: ")
+		if err != nil {
+			return false, err
+		}
+		if answer == "" {
+			break
+		}
+		if strings.EqualFold(answer, "q") || strings.EqualFold(answer, "quit") {
+			return false, nil
+		}
+		if err := toggleChoices(choices, answer); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	var picked []*changeChoice
+	for _, c := range choices {
+		if c.selected {
+			picked = append(picked, c)
+		}
+	}
+	if len(picked) == 0 {
+		return false, nil
+	}
+
+	// 2. Optionally go through the hunks of the chosen files.
+	hunkwise := false
+	for _, c := range picked {
+		if c.hunkwise() {
+			hunkwise = true
+			break
+		}
+	}
+	if hunkwise {
+		review, err := prompt.confirm("Review the chosen files hunk by hunk?")
+		if err != nil {
+			return false, err
+		}
+		if review {
+			if err := pickHunks(prompt, picked); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	// 3. Stage the result.
+	var patch strings.Builder
+	var untracked []string
+	for _, c := range picked {
+		switch {
+		case c.file == nil:
+			untracked = append(untracked, c.path)
+		case len(c.hunks) > 0 || !c.hunkwise():
+			patch.WriteString(c.file.Patch(c.hunks))
+		}
+	}
+	if patch.Len() == 0 && len(untracked) == 0 {
+		return false, nil
+	}
+
+	if patch.Len() > 0 {
+		if err := applyCached(patch.String()); err != nil {
+			return false, withExitCode(exitGitFailure, fmt.Errorf("error staging the selected hunks: %w", err))
+		}
+	}
+	if len(untracked) > 0 {
+		if err := execCommand("git", append([]string{"add", "--"}, untracked...)...).Run(); err != nil {
+			return false, withExitCode(exitGitFailure, fmt.Errorf("error staging untracked files: %w", err))
+		}
+	}
+	return true, nil
+}
+
+// unstagedChanges lists the changes in the working tree matching pathspecs
+// that are not staged: the files in "git diff" followed by untracked files.
+func unstagedChanges(pathspecs []string) ([]*changeChoice, error) {
+	output, err := execCommand("git", append([]string{"diff", "--binary", "--"}, pathspecs...)...).Output()
+	if err != nil {
+		return nil, withExitCode(exitGitFailure, fmt.Errorf("error getting unstaged diff: %w", err))
+	}
+	files, err := diff.Parse(string(output))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse unstaged diff: %w", err)
+	}
+
+	var choices []*changeChoice
+	for _, file := range files {
+		choices = append(choices, &changeChoice{path: file.Path(), file: file, hunks: file.Hunks})
+	}
+
+	untracked, err := untrackedFiles(pathspecs)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range untracked {
+		choices = append(choices, &changeChoice{path: path})
+	}
+	return choices, nil
+}
+
+// untrackedFiles lists the untracked, not ignored files matching pathspecs,
+// or in the whole repository if there are none, relative to the current
+// directory so they can be passed to "git add".
+func untrackedFiles(pathspecs []string) ([]string, error) {
+	if len(pathspecs) == 0 {
+		pathspecs = []string{":/"}
+	}
+	output, err := execCommand("git", append([]string{"ls-files", "--others", "--exclude-standard", "-z", "--"}, pathspecs...)...).Output()
+	if err != nil {
+		return nil, withExitCode(exitGitFailure, fmt.Errorf("error listing untracked files: %w", err))
+	}
+	var files []string
+	for _, path := range strings.Split(string(output), "\x00") {
+		if path != "" {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+// applyCached stages patch with "git apply --cached". It runs at the top of
+// the work tree, because from a subdirectory git apply silently skips the
+// files outside it.
+func applyCached(patch string) error {
+	top, err := execCommand("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return fmt.Errorf("could not find the top of the work tree: %w", err)
+	}
+	apply := execCommand("git", "apply", "--cached", "-")
+	apply.Dir = strings.TrimSpace(string(top))
+	apply.Stdin = strings.NewReader(patch)
+	if output, err := apply.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w\n%s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// toggleChoices applies a file list answer to choices: "a" selects all,
+// "n" none, and numbers or ranges such as "1 3-5" or "2,4" toggle those
+// entries. Nothing changes if any part of the answer is invalid.
+func toggleChoices(choices []*changeChoice, answer string) error {
+	switch strings.ToLower(answer) {
+	case "a", "all":
+		for _, c := range choices {
+			c.selected = true
+		}
+		return nil
+	case "n", "none":
+		for _, c := range choices {
+			c.selected = false
+		}
+		return nil
+	}
+
+	var toggle []int
+	for _, field := range strings.FieldsFunc(answer, func(r rune) bool { return r == ' ' || r == ',' }) {
+		from, to, isRange := strings.Cut(field, "-")
+		first, err := strconv.Atoi(from)
+		last := first
+		if err == nil && isRange {
+			last, err = strconv.Atoi(to)
+		}
+		if err != nil || first < 1 || last > len(choices) || first > last {
+			return fmt.Errorf("%q is not a file number between 1 and %d", field, len(choices))
+		}
+		for n := first; n <= last; n++ {
+			toggle = append(toggle, n-1)
+		}
+	}
+	for _, i := range toggle {
+		choices[i].selected = !choices[i].selected
+	}
+	return nil
+}
+
+// pickHunks asks about each hunk of the hunkwise choices in turn and keeps
+// only the accepted ones. The answers follow "git add -p": y stages the
+// hunk, n skips it, a and d stage or skip the rest of the file, and q
+// skips every remaining hunk.
+func pickHunks(prompt *prompter, choices []*changeChoice) error {
+	quit := false
+	for _, c := range choices {
+		if !c.hunkwise() {
+			continue
+		}
+		c.hunks = nil
+		if quit {
+			continue
+		}
+
+	hunks:
+		for i, h := range c.file.Hunks {
+			for {
+				fmt.Printf("\n%s (hunk %d of %d)\n%s", c.path, i+1, len(c.file.Hunks), h)
+				answer, err := prompt.ask("Stage this hunk [y,n,a,d,q,?]? ")
+				if err != nil {
+					return err
+				}
+				switch strings.ToLower(answer) {
+				case "y", "yes":
+					c.hunks = append(c.hunks, h)
+				case "n", "no":
+				case "a":
+					c.hunks = append(c.hunks, c.file.Hunks[i:]...)
+					break hunks
+				case "d":
+					break hunks
+				case "q":
+					quit = true
+					break hunks
+				default:
+					fmt.Println("y - stage this hunk\nn - do not stage this hunk\na - stage this and the remaining hunks of the file\nd - do not stage this or the remaining hunks of the file\nq - do not stage this or any remaining hunk")
+					continue
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
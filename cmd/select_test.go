@@ -0,0 +1,40 @@
+package cmd
+
+import "testing"
+
+func TestToggleChoices(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   []bool
+		answer  string
+		want    []bool
+		wantErr bool
+	}{
+		{name: "single numbers", start: []bool{false, false, false}, answer: "1 3", want: []bool{true, false, true}},
+		{name: "commas and ranges", start: []bool{false, false, false, false}, answer: "1,2-3", want: []bool{true, true, true, false}},
+		{name: "toggles off", start: []bool{true, true}, answer: "2", want: []bool{true, false}},
+		{name: "all", start: []bool{false, true}, answer: "a", want: []bool{true, true}},
+		{name: "none", start: []bool{true, true}, answer: "N", want: []bool{false, false}},
+		{name: "out of range changes nothing", start: []bool{false, false}, answer: "1 3", want: []bool{false, false}, wantErr: true},
+		{name: "backwards range", start: []bool{false, false}, answer: "2-1", want: []bool{false, false}, wantErr: true},
+		{name: "not a number", start: []bool{false}, answer: "x", want: []bool{false}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			choices := make([]*changeChoice, len(tt.start))
+			for i, selected := range tt.start {
+				choices[i] = &changeChoice{selected: selected}
+			}
+			err := toggleChoices(choices, tt.answer)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("toggleChoices(%q) error = %v, wantErr %v", tt.answer, err, tt.wantErr)
+			}
+			for i, c := range choices {
+				if c.selected != tt.want[i] {
+					t.Errorf("toggleChoices(%q): choice %d selected = %v, want %v", tt.answer, i+1, c.selected, tt.want[i])
+				}
+			}
+		})
+	}
+}
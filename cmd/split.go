@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/biswajitpain/gitter/internal/config"
+	"github.com/biswajitpain/gitter/internal/diff"
+	"github.com/biswajitpain/gitter/internal/llm"
+	"github.com/biswajitpain/gitter/internal/split"
+)
+
+// splitStrategies are the accepted values of --split-by.
+var splitStrategies = []string{"dir", "type", "llm"}
+
+// runSplit commits the staged changes in files as several commits, one per
+// group of the plan. Each group is staged on its own with "git apply
+// --cached" and gets its own message. If anything fails midway, the
+// commits made so far are undone and the original index is restored.
+func runSplit(prompt *prompter, files []*diff.File) error {
+	groups := planSplit(files, crSplitBy)
+
+	fmt.Printf("\nProposed commits (split by %s):\n", crSplitBy)
+	for i, g := range groups {
+		fmt.Printf("%d. %s\n", i+1, g.Name)
+		for _, part := range g.Parts {
+			note := ""
+			if len(part.Hunks) < len(part.File.Hunks) {
+				note = fmt.Sprintf(", %d of %d hunks", len(part.Hunks), len(part.File.Hunks))
+			}
+			file := *part.File
+			file.Hunks = part.Hunks
+			fmt.Printf("   %s (+%d -%d%s)\n", file.Path(), file.Added(), file.Removed(), note)
+		}
+	}
+
+	if crDryRun {
+		fmt.Println("Dry run: nothing was committed.")
+		return nil
+	}
+	if !crYes {
+		ok, err := prompt.confirm(fmt.Sprintf("Create these %d commits?", len(groups)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Split cancelled. Changes are still staged.")
+			return withExitCode(exitCancelled, nil)
+		}
+	}
+
+	headBytes, err := execCommand("git", "rev-parse", "--verify", "--quiet", "HEAD").Output()
+	if err != nil {
+		return fmt.Errorf("--split needs an existing commit to build on, commit once without --split first")
+	}
+	head := strings.TrimSpace(string(headBytes))
+	treeBytes, err := execCommand("git", "write-tree").Output()
+	if err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("could not save the index: %w", err))
+	}
+	tree := strings.TrimSpace(string(treeBytes))
+
+	restore := func() {
+		resetErr := execCommand("git", "reset", "--quiet", "--soft", head).Run()
+		readErr := execCommand("git", "read-tree", tree).Run()
+		if resetErr != nil || readErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not restore the original state. Run 'git reset --soft %s && git read-tree %s' to do it by hand.\n", head, tree)
+			return
+		}
+		fmt.Println("Undid the split commits and restored the original index.")
+	}
+
+	binaries, err := binaryPatches()
+	if err != nil {
+		return withExitCode(exitGitFailure, err)
+	}
+
+	if err := execCommand("git", "reset", "--quiet").Run(); err != nil {
+		return withExitCode(exitGitFailure, fmt.Errorf("error clearing the index: %w", err))
+	}
+
+	for i, g := range groups {
+		fmt.Printf("\nCommit %d of %d: %s\n", i+1, len(groups), g.Name)
+
+		patch := g.Patch()
+		if err := applyCached(stagingPatch(g, binaries)); err != nil {
+			restore()
+			return withExitCode(exitGitFailure, fmt.Errorf("error staging %q: %w", g.Name, err))
+		}
+
+		groupFiles := g.Files()
+		hint := splitHint(g.Name)
+		if crMessage != "" {
+			hint += "\n\n" + crMessage
+		}
+		generate := func(hint string) string {
+			if crNoLLM {
+				return generateSimpleCommitMessage(hint, groupFiles)
+			}
+			return generateCommitMessage(hint, patch, groupFiles)
+		}
+
+		message, accepted := generate(hint), true
+		if !crYes {
+			regenerate := func(extra string) string {
+				return generate(hint + "\n\nAdditional guidance: " + extra)
+			}
+			template := func() string {
+				return generateSimpleCommitMessage(hint, groupFiles)
+			}
+			message, accepted, err = reviewCommitMessage(prompt, message, regenerate, template)
+			if err != nil {
+				restore()
+				return err
+			}
+		}
+		if !accepted {
+			restore()
+			return withExitCode(exitCancelled, nil)
+		}
+
+		if err := execCommand("git", "commit", "--quiet", "-m", message).Run(); err != nil {
+			restore()
+			return withExitCode(exitGitFailure, fmt.Errorf("error committing %q: %w", g.Name, err))
+		}
+	}
+
+	if final, err := execCommand("git", "rev-parse", "HEAD^{tree}").Output(); err == nil && strings.TrimSpace(string(final)) != tree {
+		fmt.Fprintf(os.Stderr, "Warning: the commits do not add up to the staged changes; compare with 'git diff %s' and 'git diff --cached'.\n", tree)
+	}
+	fmt.Printf("\nCreated %d commits.\n", len(groups))
+	return nil
+}
+
+// binaryPatches returns the staged changes to binary files, by path, with
+// the full contents "git apply" needs to stage them. The diff the groups
+// are made from leaves the contents out, as they mean nothing to the LLM.
+func binaryPatches() (map[string]string, error) {
+	output, err := execCommand("git", "diff", "--staged", "--binary").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting the binary changes: %w", err)
+	}
+	files, err := diff.Parse(string(output))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the binary changes: %w", err)
+	}
+	patches := map[string]string{}
+	for _, f := range files {
+		if f.Binary {
+			patches[f.Path()] = f.Patch(f.Hunks)
+		}
+	}
+	return patches, nil
+}
+
+// stagingPatch renders g as a patch for "git apply --cached", taking binary
+// files from binaries.
+func stagingPatch(g *split.Group, binaries map[string]string) string {
+	var b strings.Builder
+	for _, part := range g.Parts {
+		if patch, ok := binaries[part.File.Path()]; ok && part.File.Binary {
+			b.WriteString(patch)
+			continue
+		}
+		b.WriteString(part.Patch())
+	}
+	return b.String()
+}
+
+// planSplit groups files by the given strategy. LLM clustering falls back
+// to grouping by directory if no LLM is configured or its answer is unusable.
+func planSplit(files []*diff.File, by string) []*split.Group {
+	switch by {
+	case "type":
+		return split.By(files, split.Kind)
+	case "llm":
+		groups, err := clusterWithLLM(files)
+		if err == nil {
+			return groups
+		}
+		fmt.Fprintf(os.Stderr, "Warning: LLM clustering failed, splitting by directory: %v\n", err)
+	}
+	return split.By(files, split.Directory)
+}
+
+// clusterWithLLM asks the configured LLM to group the hunks of files.
+func clusterWithLLM(files []*diff.File) ([]*split.Group, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load config: %v\n", err)
+	}
+	llmClient, err := newLLMClientFunc(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	policy, err := loadPathPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	units := split.Units(files)
+	fmt.Printf("Grouping %d hunks with %s (press Ctrl-C to cancel)...\n", len(units), cfg.Provider)
+	reply, err := llm.ClusterHunks(ctx, llmClient, split.DescribeUnits(units, 40, policy.AllowedFile))
+	if err != nil {
+		return nil, err
+	}
+	return split.FromClusters(units, reply)
+}
+
+// splitHint turns a group name into the description used to generate the
+// group's commit message.
+func splitHint(name string) string {
+	switch {
+	case crSplitBy == "llm":
+		return name
+	case name == "(root)":
+		return "update top-level files"
+	case crSplitBy == "type" && name != "tests" && name != "docs" && name != "ci" && name != "build":
+		return "update " + name + " files"
+	}
+	return "update " + name
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/biswajitpain/gitter/internal/diff"
+)
+
+func TestRunSplit_BinaryFile(t *testing.T) {
+	inTestRepo(t)
+	writeFile(t, "src/main.go", "package main\n")
+	writeFile(t, "assets/logo.png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x01")
+	git(t, "add", "-A")
+
+	output, err := execCommand("git", "diff", "--staged").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := diff.Parse(string(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldYes, oldNoLLM, oldSplitBy := crYes, crNoLLM, crSplitBy
+	defer func() { crYes, crNoLLM, crSplitBy = oldYes, oldNoLLM, oldSplitBy }()
+	crYes, crNoLLM, crSplitBy = true, true, "dir"
+
+	captureStdout(func() {
+		err = runSplit(newPrompter(), files)
+	})
+	if err != nil {
+		t.Fatalf("runSplit() error: %v", err)
+	}
+	if got := git(t, "rev-list", "--count", "HEAD"); got != "3" {
+		t.Errorf("HEAD has %s commits, want 3", got)
+	}
+	if got := git(t, "status", "--porcelain"); got != "" {
+		t.Errorf("changes left after the split:\n%s", got)
+	}
+	if got := git(t, "ls-tree", "-r", "--name-only", "HEAD"); got != "README.md\nassets/logo.png\nsrc/main.go" {
+		t.Errorf("HEAD has files:\n%s", got)
+	}
+}
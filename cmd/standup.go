@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/biswajitpain/gitter/internal/config"
+	"github.com/biswajitpain/gitter/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+var (
+	standupSince  string
+	standupAuthor string
+	standupNoLLM  bool
+)
+
+// standupCmd represents the standup command
+var standupCmd = &cobra.Command{
+	Use:   "standup",
+	Short: "Summarise your recent commits for a status update",
+	Long: `Collect the commits made since a point in time across all local branches
+of the current repository, and of any repositories listed with
+'gitter config --standup-repos', grouped by repository and branch.
+
+With an LLM configured the activity is summarised into a few bullets
+ready to paste into a status post; otherwise, or with --no-llm, the
+commits are listed as plain bullets.
+
+--since accepts anything 'git log --since' does, such as "yesterday",
+"monday" or "2 days ago". --author defaults to "me", your git
+user.email in each repository; use "all" for everyone.`,
+	Example: `  gitter standup
+  gitter standup --since monday
+  gitter standup --since "2 days ago" --author all --no-llm`,
+	Args: cobra.NoArgs,
+	RunE: handleStandupCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(standupCmd)
+
+	standupCmd.Flags().StringVar(&standupSince, "since", "yesterday", "Only include commits more recent than this date")
+	standupCmd.Flags().StringVar(&standupAuthor, "author", "me", "Author to report on: \"me\", \"all\" or part of a name or email")
+	standupCmd.Flags().BoolVar(&standupNoLLM, "no-llm", false, "List the commits instead of summarising them with the LLM")
+}
+
+// standupCommit is a commit in a standup report.
+type standupCommit struct {
+	hash    string
+	subject string
+}
+
+// branchActivity is the commits first found on one branch.
+type branchActivity struct {
+	branch  string
+	commits []standupCommit
+}
+
+// repoActivity is the activity in one repository.
+type repoActivity struct {
+	name     string
+	branches []branchActivity
+}
+
+func handleStandupCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load config: %v\n", err)
+	}
+
+	var repos []string
+	if err := execCommand("git", "rev-parse", "--is-inside-work-tree").Run(); err == nil {
+		repos = append(repos, ".")
+	}
+	repos = append(repos, cfg.StandupRepos...)
+	if len(repos) == 0 {
+		return errors.New("not a git repository and no repositories configured with 'gitter config --standup-repos'")
+	}
+
+	var activity []repoActivity
+	seen := map[string]bool{}
+	for _, repo := range repos {
+		top, err := execCommand("git", "-C", expandHome(repo), "rev-parse", "--show-toplevel").Output()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s, not a git repository\n", repo)
+			continue
+		}
+		path := strings.TrimSpace(string(top))
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		repoActivity, err := collectActivity(path, standupSince, standupAuthor)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", repo, err)
+			continue
+		}
+		if len(repoActivity.branches) > 0 {
+			activity = append(activity, repoActivity)
+		}
+	}
+
+	if len(activity) == 0 {
+		fmt.Printf("No commits since %s.\n", standupSince)
+		return nil
+	}
+
+	report := formatActivity(activity)
+	if !standupNoLLM {
+		if summary := summarizeActivity(cfg, report); summary != "" {
+			fmt.Println(summary)
+			return nil
+		}
+	}
+	fmt.Print(report)
+	return nil
+}
+
+// collectActivity returns the commits since since by author in the
+// repository at path. Each commit is reported once, under the first branch
+// it is found on, starting with the current branch.
+func collectActivity(path, since, author string) (repoActivity, error) {
+	activity := repoActivity{name: filepath.Base(path)}
+
+	// git matches --author as a regular expression, in which the dots and
+	// plus signs of an address would match other authors too.
+	authorArgs := []string{"--fixed-strings"}
+	switch author {
+	case "all":
+	case "me":
+		email, err := execCommand("git", "-C", path, "config", "user.email").Output()
+		if err != nil || strings.TrimSpace(string(email)) == "" {
+			return activity, errors.New("user.email is not set, use --author")
+		}
+		authorArgs = append(authorArgs, "--author=<"+strings.TrimSpace(string(email))+">")
+	default:
+		authorArgs = append(authorArgs, "--author="+author)
+	}
+
+	refs, err := execCommand("git", "-C", path, "for-each-ref", "--format=%(HEAD) %(refname:short)", "refs/heads").Output()
+	if err != nil {
+		return activity, fmt.Errorf("could not list branches: %w", err)
+	}
+	var branches []string
+	for _, line := range strings.Split(string(refs), "\n") {
+		// Each line is "* name" for the current branch, "  name" otherwise.
+		if len(line) < 3 {
+			continue
+		}
+		branch := line[2:]
+		if strings.HasPrefix(line, "*") {
+			branches = append([]string{branch}, branches...)
+		} else {
+			branches = append(branches, branch)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, branch := range branches {
+		logArgs := append([]string{"-C", path, "log", "--no-merges", "--since=" + since, "--format=%H%x00%s"}, authorArgs...)
+		output, err := execCommand("git", append(logArgs, "refs/heads/"+branch, "--")...).Output()
+		if err != nil {
+			return activity, fmt.Errorf("could not read log of %s: %w", branch, err)
+		}
+
+		var commits []standupCommit
+		for _, line := range strings.Split(string(output), "\n") {
+			hash, subject, ok := strings.Cut(line, "\x00")
+			if !ok || seen[hash] {
+				continue
+			}
+			seen[hash] = true
+			commits = append(commits, standupCommit{hash: hash, subject: subject})
+		}
+		if len(commits) > 0 {
+			activity.branches = append(activity.branches, branchActivity{branch: branch, commits: commits})
+		}
+	}
+	return activity, nil
+}
+
+// formatActivity renders activity as a Markdown bullet list grouped by
+// repository and branch, newest commits first as in 'git log'.
+func formatActivity(activity []repoActivity) string {
+	var b strings.Builder
+	for i, repo := range activity {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		for _, branch := range repo.branches {
+			fmt.Fprintf(&b, "%s (%s):\n", repo.name, branch.branch)
+			for _, commit := range branch.commits {
+				fmt.Fprintf(&b, "- %s (%s)\n", commit.subject, commit.hash[:min(len(commit.hash), 7)])
+			}
+		}
+	}
+	return b.String()
+}
+
+// summarizeActivity asks the configured LLM to turn report into a short
+// status update. It returns an empty string, after telling the user why,
+// if no LLM is configured or the request fails.
+func summarizeActivity(cfg config.Config, report string) string {
+	llmClient, err := newLLMClientFunc(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "No LLM configured (%v), listing commits.\n", err)
+		return ""
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	fmt.Fprintf(os.Stderr, "Summarising with %s (press Ctrl-C to cancel)...\n", cfg.Provider)
+	summary, err := llm.SummarizeActivity(ctx, llmClient, report)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		fmt.Fprintln(os.Stderr, "LLM request cancelled, listing commits.")
+		return ""
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: LLM request failed, listing commits: %v\n", err)
+		return ""
+	}
+	return strings.TrimSpace(summary)
+}
+
+// expandHome replaces a leading "~/" in path with the user's home directory.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import "testing"
+
+func TestFormatActivity(t *testing.T) {
+	activity := []repoActivity{
+		{name: "gitter", branches: []branchActivity{
+			{branch: "main", commits: []standupCommit{
+				{hash: "1111111aaaa", subject: "feat: add standup"},
+				{hash: "2222222bbbb", subject: "fix: branch parsing"},
+			}},
+			{branch: "docs", commits: []standupCommit{{hash: "3333333cccc", subject: "docs: standup"}}},
+		}},
+		{name: "infra", branches: []branchActivity{
+			{branch: "main", commits: []standupCommit{{hash: "4444444dddd", subject: "ci: cache modules"}}},
+		}},
+	}
+
+	want := "gitter (main):\n- feat: add standup (1111111)\n- fix: branch parsing (2222222)\n" +
+		"gitter (docs):\n- docs: standup (3333333)\n" +
+		"\ninfra (main):\n- ci: cache modules (4444444)\n"
+	if got := formatActivity(activity); got != want {
+		t.Errorf("formatActivity() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCollectActivity_EmailIsNotARegexp(t *testing.T) {
+	repo := inTestRepo(t)
+	git(t, "config", "user.email", "a.b+c@x.com")
+	for _, author := range []string{"Me <a.b+c@x.com>", "Other <axb+c@x.com>", "Third <a.bbc@x.com>"} {
+		git(t, "commit", "-q", "--allow-empty", "--author", author, "-m", "work by "+author)
+	}
+
+	activity, err := collectActivity(repo, "1 year ago", "me")
+	if err != nil {
+		t.Fatalf("collectActivity() error: %v", err)
+	}
+	if len(activity.branches) != 1 || len(activity.branches[0].commits) != 1 {
+		t.Fatalf("collectActivity() = %+v, want one commit", activity)
+	}
+	if got := activity.branches[0].commits[0].subject; got != "work by Me <a.b+c@x.com>" {
+		t.Errorf("collectActivity() found %q", got)
+	}
+}
@@ -18,23 +18,26 @@ var timeNow = time.Now
 var osExit = os.Exit
 
 // captureOutput is a generic helper to capture output from a given *os.File (e.g., os.Stdout, os.Stderr).
-// It returns a bytes.Buffer to read the output from, and a cleanup function.
+// It returns a bytes.Buffer to read the output from, and a cleanup function
+// that must be called before the buffer is read.
 func captureOutput(target *os.File) (outputBuffer *bytes.Buffer, cleanup func()) {
-	oldTarget := target
+	oldTarget := *target
 	r, w, _ := os.Pipe()
-	*target = *r // Redirect target (os.Stdout or os.Stderr) to the read end of the pipe
+	*target = *w // Redirect target (os.Stdout or os.Stderr) to the write end of the pipe
 
 	outputBuffer = new(bytes.Buffer)
-	// Start a goroutine to copy data from the read end of the pipe to the buffer
-	// and then restore the original target.
+	// Start a goroutine to copy data from the read end of the pipe to the buffer.
+	done := make(chan struct{})
 	go func() {
 		io.Copy(outputBuffer, r)
 		r.Close()
+		close(done)
 	}()
 
 	cleanup = func() {
 		w.Close() // Close the write end of the pipe
-		*target = *oldTarget // Restore the original target
+		<-done
+		*target = oldTarget // Restore the original target
 	}
 
 	return outputBuffer, cleanup
@@ -44,8 +47,8 @@ func captureOutput(target *os.File) (outputBuffer *bytes.Buffer, cleanup func())
 // It returns the captured string.
 func captureStdout(f func()) string {
 	outputBuffer, cleanup := captureOutput(os.Stdout)
-	defer cleanup()
 	f()
+	cleanup()
 	return outputBuffer.String()
 }
 
@@ -53,8 +56,8 @@ func captureStdout(f func()) string {
 // It returns the captured string.
 func captureStderr(f func()) string {
 	outputBuffer, cleanup := captureOutput(os.Stderr)
-	defer cleanup()
 	f()
+	cleanup()
 	return outputBuffer.String()
 }
 
@@ -0,0 +1,261 @@
+// Package audit keeps a log of every request gitter sends to an LLM, one
+// JSON object per line, so that it can be checked later exactly what left
+// the machine.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/biswajitpain/gitter/internal/config"
+)
+
+// FileName is the name of the audit log in the gitter config directory.
+const FileName = "audit.jsonl"
+
+// Entry is one LLM request and its outcome.
+type Entry struct {
+	ID       string    `json:"id"`
+	Time     time.Time `json:"time"`
+	Repo     string    `json:"repo,omitempty"`
+	Provider string    `json:"provider"`
+	Model    string    `json:"model"`
+	// Kind is "commit message" for commit message requests and
+	// "completion" for everything else.
+	Kind string `json:"kind"`
+	// PromptSHA256 is the hash of the system and user prompt as sent, after
+	// secrets were masked. System and Prompt hold them in full only when
+	// full prompts are logged.
+	PromptSHA256 string `json:"prompt_sha256"`
+	PromptBytes  int    `json:"prompt_bytes"`
+	System       string `json:"system,omitempty"`
+	Prompt       string `json:"prompt,omitempty"`
+	Response     string `json:"response,omitempty"`
+	Error        string `json:"error,omitempty"`
+	LatencyMS    int64  `json:"latency_ms"`
+	// InputTokens and OutputTokens are zero if the provider did not
+	// report its usage.
+	InputTokens  int `json:"input_tokens,omitempty"`
+	OutputTokens int `json:"output_tokens,omitempty"`
+}
+
+// Path returns the path of the audit log, next to the config file.
+func Path() (string, error) {
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), FileName), nil
+}
+
+// HashPrompt returns the hex SHA-256 of a system and user prompt.
+func HashPrompt(system, prompt string) string {
+	sum := sha256.Sum256([]byte(system + "\x00" + prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewID returns a random identifier for an entry.
+func NewID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%012x", time.Now().UnixNano()&0xffffffffffff)
+	}
+	return hex.EncodeToString(b)
+}
+
+// logMu serialises writes from concurrent requests, such as the per-file
+// summaries of a large diff. Other gitter processes are kept out by the
+// lock file, see lockLog.
+var logMu sync.Mutex
+
+// lockTimeout is how long Append and Prune wait for another gitter process
+// to release the log.
+const lockTimeout = 5 * time.Second
+
+// staleLockAge is the age after which a lock file is taken to be left
+// behind by a gitter process that died, as no write takes this long.
+const staleLockAge = time.Minute
+
+// lockLog locks the log at path against other goroutines and, with a
+// "<path>.lock" file created exclusively as git does for its own files,
+// other gitter processes. It returns the function that releases the lock.
+func lockLog(path string) (func(), error) {
+	logMu.Lock()
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return func() {
+				os.Remove(lockPath)
+				logMu.Unlock()
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			logMu.Unlock()
+			return nil, fmt.Errorf("could not lock audit log: %w", err)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			logMu.Unlock()
+			return nil, fmt.Errorf("audit log is locked by another gitter process; remove %s if none is running", lockPath)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Append adds e to the log at path, creating the log readable only by the
+// user if it does not exist.
+func Append(path string, e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("could not encode audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	unlock, err := lockLog(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("could not open audit log: %w", err)
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return fmt.Errorf("could not write audit log: %w", err)
+	}
+	return file.Close()
+}
+
+// Read returns the entries of the log at path, oldest first, and the
+// number of lines that could not be parsed. A missing log has no entries.
+func Read(path string) ([]Entry, int, error) {
+	var entries []Entry
+	bad := 0
+	err := eachLine(path, func(line []byte) error {
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			bad++
+			return nil
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	return entries, bad, err
+}
+
+// eachLine calls fn with every non-empty line of the file at path. Lines
+// are not limited in length, since full prompts can be large.
+func eachLine(path string, fn func(line []byte) error) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not open audit log: %w", err)
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	for {
+		line, err := r.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if err := fn(line); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not read audit log: %w", err)
+		}
+	}
+}
+
+// Find returns the entry whose ID is or starts with id.
+func Find(entries []Entry, id string) (Entry, error) {
+	var found []Entry
+	for _, e := range entries {
+		if e.ID == id {
+			return e, nil
+		}
+		if strings.HasPrefix(e.ID, id) {
+			found = append(found, e)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Entry{}, fmt.Errorf("no audit entry %q", id)
+	case 1:
+		return found[0], nil
+	default:
+		return Entry{}, fmt.Errorf("audit entry %q is ambiguous, it matches %d entries", id, len(found))
+	}
+}
+
+// Prune removes the entries older than cutoff from the log at path and
+// returns how many it removed. Lines that cannot be parsed are kept, so
+// that a damaged log is not silently lost. The log stays locked from the
+// read to the rewrite, so that no entry appended in between is lost.
+func Prune(path string, cutoff time.Time) (int, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	unlock, err := lockLog(path)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	var kept bytes.Buffer
+	removed := 0
+	err = eachLine(path, func(line []byte) error {
+		var e Entry
+		if json.Unmarshal(line, &e) == nil && e.Time.Before(cutoff) {
+			removed++
+			return nil
+		}
+		kept.Write(line)
+		kept.WriteByte('\n')
+		return nil
+	})
+	if err != nil || removed == 0 {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), FileName+".*")
+	if err != nil {
+		return 0, fmt.Errorf("could not rewrite audit log: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(kept.Bytes()); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("could not rewrite audit log: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("could not rewrite audit log: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("could not rewrite audit log: %w", err)
+	}
+	return removed, nil
+}
@@ -0,0 +1,190 @@
+package audit_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/biswajitpain/gitter/internal/audit"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), audit.FileName)
+
+	entries, bad, err := audit.Read(path)
+	if err != nil || bad != 0 || len(entries) != 0 {
+		t.Fatalf("Read() of a missing log = %v, %d, %v, want nothing", entries, bad, err)
+	}
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, id := range []string{"aaa111", "bbb222"} {
+		e := audit.Entry{ID: id, Time: start.Add(time.Duration(i) * time.Hour), Provider: "openai", Response: "line one\nline two"}
+		if err := audit.Append(path, e); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("audit log permissions = %o, want 600", perm)
+	}
+
+	entries, bad, err = audit.Read(path)
+	if err != nil || bad != 0 {
+		t.Fatalf("Read() = %d bad lines, error %v", bad, err)
+	}
+	if len(entries) != 2 || entries[0].ID != "aaa111" || entries[1].Response != "line one\nline two" {
+		t.Errorf("Read() = %+v, want both entries in order", entries)
+	}
+}
+
+func TestRead_SkipsDamagedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), audit.FileName)
+	data := `{"id":"aaa111","provider":"openai"}` + "\n" + `{"id":"trunc` + "\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	entries, bad, err := audit.Read(path)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if len(entries) != 1 || bad != 1 {
+		t.Errorf("Read() = %d entries and %d bad lines, want 1 and 1", len(entries), bad)
+	}
+}
+
+func TestFind(t *testing.T) {
+	entries := []audit.Entry{{ID: "ab12cd"}, {ID: "ab34ef"}, {ID: "ff0000"}}
+	if e, err := audit.Find(entries, "ab3"); err != nil || e.ID != "ab34ef" {
+		t.Errorf("Find(ab3) = %v, %v, want ab34ef", e.ID, err)
+	}
+	if _, err := audit.Find(entries, "ab"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("Find(ab) error = %v, want ambiguous", err)
+	}
+	if _, err := audit.Find(entries, "0"); err == nil {
+		t.Error("Find() of an unknown ID should return an error")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), audit.FileName)
+	now := time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)
+	for _, age := range []int{40, 31, 10, 0} {
+		e := audit.Entry{ID: audit.NewID(), Time: now.AddDate(0, 0, -age)}
+		if err := audit.Append(path, e); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("not json\n")
+	f.Close()
+
+	removed, err := audit.Prune(path, now.AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatalf("Prune() error: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Prune() removed %d entries, want 2", removed)
+	}
+	entries, bad, err := audit.Read(path)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if len(entries) != 2 || bad != 1 {
+		t.Errorf("after Prune() the log has %d entries and %d bad lines, want 2 and 1", len(entries), bad)
+	}
+}
+
+func TestPrune_KeepsConcurrentAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), audit.FileName)
+	now := time.Now()
+	for i := 0; i < 200; i++ {
+		if err := audit.Append(path, audit.Entry{ID: audit.NewID(), Time: now.AddDate(0, 0, -60)}); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := audit.Append(path, audit.Entry{ID: audit.NewID(), Time: now}); err != nil {
+				t.Errorf("Append() error: %v", err)
+			}
+		}()
+	}
+	if _, err := audit.Prune(path, now.AddDate(0, 0, -30)); err != nil {
+		t.Fatalf("Prune() error: %v", err)
+	}
+	wg.Wait()
+
+	entries, _, err := audit.Read(path)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if len(entries) != 20 {
+		t.Errorf("after Prune() the log has %d entries, want the 20 appended meanwhile", len(entries))
+	}
+}
+
+func TestAppend_WaitsForLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), audit.FileName)
+	// Another gitter process holds the lock.
+	lockPath := path + ".lock"
+	if err := os.WriteFile(lockPath, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() { done <- audit.Append(path, audit.Entry{ID: "aaa111", Time: time.Now()}) }()
+	select {
+	case err := <-done:
+		t.Fatalf("Append() returned %v while the log was locked", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	os.Remove(lockPath)
+	if err := <-done; err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	if entries, _, _ := audit.Read(path); len(entries) != 1 {
+		t.Errorf("log has %d entries, want 1", len(entries))
+	}
+	if _, err := os.Stat(lockPath); err == nil {
+		t.Error("Append() left the lock file behind")
+	}
+}
+
+func TestAppend_TakesOverStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), audit.FileName)
+	lockPath := path + ".lock"
+	if err := os.WriteFile(lockPath, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := audit.Append(path, audit.Entry{ID: "aaa111", Time: time.Now()}); err != nil {
+		t.Fatalf("Append() with a stale lock: %v", err)
+	}
+}
+
+func TestHashPrompt(t *testing.T) {
+	if audit.HashPrompt("a", "bc") == audit.HashPrompt("ab", "c") {
+		t.Error("HashPrompt() should keep the system and user prompt apart")
+	}
+	if got := len(audit.HashPrompt("", "")); got != 64 {
+		t.Errorf("HashPrompt() has %d hex digits, want 64", got)
+	}
+}
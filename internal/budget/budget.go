@@ -0,0 +1,376 @@
+// Package budget fits a unified diff into the token budget of an LLM prompt.
+//
+// Generated, vendored and lockfile changes are abbreviated to a stat line, and
+// when the remaining diff is still too large the biggest files are summarised
+// one by one so the final prompt describes every change without overflowing
+// the model's context window.
+package budget
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/biswajitpain/gitter/internal/diff"
+)
+
+// DefaultMaxTokens is the diff budget used when none is configured.
+const DefaultMaxTokens = 8000
+
+// DefaultConcurrency is the number of files summarised in parallel.
+const DefaultConcurrency = 4
+
+// summaryTokens is the estimated cost of one summary line in the final prompt.
+const summaryTokens = 80
+
+// Summarizer returns a short description of the change to a single file.
+type Summarizer func(ctx context.Context, path string, fileDiff string) (string, error)
+
+// Options controls how a diff is fitted into the budget.
+type Options struct {
+	// MaxTokens is the budget for the whole diff. Defaults to DefaultMaxTokens.
+	MaxTokens int
+	// Concurrency is the number of files summarised at once. Defaults to DefaultConcurrency.
+	Concurrency int
+}
+
+// Result is a diff that fits the budget, together with a record of what was
+// left out of it.
+type Result struct {
+	// Diff is the text to send in place of the original diff.
+	Diff string
+	// Abbreviated lists generated, vendored and lockfile paths reduced to stats.
+	Abbreviated []string
+	// Summarized lists paths replaced by an LLM summary.
+	Summarized []string
+	// Omitted lists paths dropped entirely because they could not be summarised.
+	Omitted []string
+}
+
+// Changed reports whether the diff had to be reduced to fit the budget.
+func (r Result) Changed() bool {
+	return len(r.Abbreviated) > 0 || len(r.Summarized) > 0 || len(r.Omitted) > 0
+}
+
+// EstimateTokens returns a rough token count for s, assuming about four
+// characters per token as is typical for English text and source code.
+func EstimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// disposition says how a file appears in the prepared diff.
+type disposition int
+
+const (
+	verbatim disposition = iota
+	abbreviated
+	summarized
+	omitted
+)
+
+// fileDiff is the part of a diff belonging to a single file.
+type fileDiff struct {
+	path    string
+	text    string
+	added   int
+	removed int
+	how     disposition
+	summary string
+	// reason explains why an omitted file was left out.
+	reason string
+}
+
+// stat returns a one-line description of the file's size.
+func (f *fileDiff) stat() string {
+	return fmt.Sprintf("%s (+%d -%d lines)", f.path, f.added, f.removed)
+}
+
+// Prepare reduces diffText until it fits opts.MaxTokens. Files are
+// summarised with summarize, largest first; a nil summarize omits them
+// instead. The returned diff ends with notes naming every file that is not
+// included verbatim so the model can mention them.
+func Prepare(ctx context.Context, diffText string, opts Options, summarize Summarizer) Result {
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = DefaultMaxTokens
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+
+	files := splitFiles(diffText)
+	var result Result
+
+	total := 0
+	for _, f := range files {
+		if IsGenerated(f.path, f.text) {
+			f.how = abbreviated
+			continue
+		}
+		total += EstimateTokens(f.text)
+	}
+
+	if total > opts.MaxTokens {
+		// Pick the largest files until the rest fits alongside their summaries.
+		candidates := make([]*fileDiff, 0, len(files))
+		for _, f := range files {
+			if f.how == verbatim {
+				candidates = append(candidates, f)
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return len(candidates[i].text) > len(candidates[j].text)
+		})
+
+		var selected []*fileDiff
+		for _, f := range candidates {
+			if total <= opts.MaxTokens {
+				break
+			}
+			total -= EstimateTokens(f.text) - summaryTokens
+			selected = append(selected, f)
+		}
+		summarizeFiles(ctx, selected, opts, summarize)
+	}
+
+	for _, f := range files {
+		switch f.how {
+		case abbreviated:
+			result.Abbreviated = append(result.Abbreviated, f.path)
+		case summarized:
+			result.Summarized = append(result.Summarized, f.path)
+		case omitted:
+			result.Omitted = append(result.Omitted, f.path)
+		}
+	}
+
+	result.Diff = render(files, opts.MaxTokens)
+	return result
+}
+
+// summarizeFiles fills in the summary of each file, running up to
+// opts.Concurrency summarisations at once. Files that cannot be summarised
+// are marked as omitted.
+func summarizeFiles(ctx context.Context, files []*fileDiff, opts Options, summarize Summarizer) {
+	if summarize == nil {
+		for _, f := range files {
+			f.how = omitted
+			f.reason = "too large"
+		}
+		return
+	}
+
+	// Each file gets at most half the budget so a single huge file cannot
+	// overflow the summarisation request either.
+	maxChars := opts.MaxTokens * 4 / 2
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.Concurrency)
+	for _, f := range files {
+		wg.Add(1)
+		go func(f *fileDiff) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			text := f.text
+			if len(text) > maxChars {
+				text = text[:maxChars] + "\n[... diff truncated ...]\n"
+			}
+			summary, err := summarize(ctx, f.path, text)
+			summary = strings.Join(strings.Fields(summary), " ")
+			if err != nil || summary == "" {
+				f.how = omitted
+				f.reason = "too large and could not be summarised"
+				return
+			}
+			f.how = summarized
+			f.summary = summary
+		}(f)
+	}
+	wg.Wait()
+}
+
+// render assembles the final diff text followed by notes about every file
+// that was not included verbatim, truncating the verbatim part if the
+// summaries pushed it over maxTokens.
+func render(files []*fileDiff, maxTokens int) string {
+	var body, notes strings.Builder
+	var summaryLines, abbreviatedLines, omittedLines []string
+	for _, f := range files {
+		switch f.how {
+		case verbatim:
+			body.WriteString(f.text)
+		case summarized:
+			summaryLines = append(summaryLines, fmt.Sprintf("- %s: %s", f.stat(), f.summary))
+		case abbreviated:
+			abbreviatedLines = append(abbreviatedLines, "- "+f.stat())
+		case omitted:
+			omittedLines = append(omittedLines, fmt.Sprintf("- %s: %s", f.stat(), f.reason))
+		}
+	}
+
+	if len(summaryLines) > 0 {
+		notes.WriteString("\nSummarised files (diff too large to include verbatim):\n")
+		notes.WriteString(strings.Join(summaryLines, "\n") + "\n")
+	}
+	if len(abbreviatedLines) > 0 {
+		notes.WriteString("\nGenerated, vendored or lockfile changes (diff omitted):\n")
+		notes.WriteString(strings.Join(abbreviatedLines, "\n") + "\n")
+	}
+	if len(omittedLines) > 0 {
+		notes.WriteString("\nOmitted files:\n")
+		notes.WriteString(strings.Join(omittedLines, "\n") + "\n")
+	}
+
+	text := body.String()
+	if maxChars := (maxTokens - EstimateTokens(notes.String())) * 4; maxChars > 0 && len(text) > maxChars {
+		text = text[:maxChars] + "\n[... diff truncated ...]\n"
+	}
+	return text + notes.String()
+}
+
+// splitFiles splits a unified diff into per-file sections. A diff that
+// cannot be parsed is treated as a single section so it is still truncated
+// rather than sent whole.
+func splitFiles(diffText string) []*fileDiff {
+	parsed, err := diff.Parse(diffText)
+	if err != nil {
+		return []*fileDiff{{path: "(unparsed diff)", text: diffText}}
+	}
+
+	files := make([]*fileDiff, 0, len(parsed))
+	for _, f := range parsed {
+		files = append(files, &fileDiff{
+			path:    f.Path(),
+			text:    f.String(),
+			added:   f.Added(),
+			removed: f.Removed(),
+		})
+	}
+	return files
+}
+
+// lockfiles are dependency lock files whose diffs carry no useful intent.
+var lockfiles = map[string]bool{
+	"go.sum":              true,
+	"package-lock.json":   true,
+	"npm-shrinkwrap.json": true,
+	"yarn.lock":           true,
+	"pnpm-lock.yaml":      true,
+	"bun.lockb":           true,
+	"Cargo.lock":          true,
+	"Gemfile.lock":        true,
+	"composer.lock":       true,
+	"poetry.lock":         true,
+	"Pipfile.lock":        true,
+	"uv.lock":             true,
+	"mix.lock":            true,
+	"pubspec.lock":        true,
+	"flake.lock":          true,
+	"packages.lock.json":  true,
+	"gradle.lockfile":     true,
+}
+
+// generatedSuffixes are file name endings used by common code generators and minifiers.
+var generatedSuffixes = []string{
+	".pb.go", ".pb.gw.go", "_generated.go", ".gen.go", "_pb2.py", "_pb2_grpc.py",
+	".g.dart", ".freezed.dart", ".min.js", ".min.css", ".js.map", ".css.map",
+}
+
+// vendorDirs are directories holding third-party code checked into the repository.
+var vendorDirs = []string{"vendor/", "node_modules/", "bower_components/", "Pods/"}
+
+// markerLines is how many lines at the top of a file are searched for a
+// generated-code marker.
+const markerLines = 5
+
+// IsGenerated reports whether a file is a lockfile, vendored dependency or
+// generated source, judged by its path and by the conventional "Code
+// generated ... DO NOT EDIT." and "@generated" markers at the top of the
+// file.
+func IsGenerated(filePath string, fileDiff string) bool {
+	if lockfiles[path.Base(filePath)] {
+		return true
+	}
+	for _, suffix := range generatedSuffixes {
+		if strings.HasSuffix(filePath, suffix) {
+			return true
+		}
+	}
+	for _, dir := range vendorDirs {
+		if strings.HasPrefix(filePath, dir) || strings.Contains(filePath, "/"+dir) {
+			return true
+		}
+	}
+	for _, line := range topLines(fileDiff) {
+		if strings.Contains(line, "Code generated") && strings.Contains(line, "DO NOT EDIT") || strings.Contains(line, "@generated") {
+			return true
+		}
+	}
+	return false
+}
+
+// topLines returns the first markerLines lines of the new version of the
+// file, so that a file merely mentioning a marker further down is not taken
+// for generated code. They come from the diff if its first hunk starts at
+// the top of the file, and otherwise from the file's blob.
+func topLines(fileDiff string) []string {
+	var lines []string
+	blob := ""
+	inHunk := false
+	for _, line := range strings.Split(fileDiff, "\n") {
+		if !inHunk {
+			// "index <old>..<new> <mode>" names the blobs of both versions.
+			if rest, ok := strings.CutPrefix(line, "index "); ok {
+				hashes, _, _ := strings.Cut(rest, " ")
+				_, blob, _ = strings.Cut(hashes, "..")
+			}
+			if !strings.HasPrefix(line, "@@ ") {
+				continue
+			}
+			// The new range is "+1" or "+1,n" when the hunk starts at the top.
+			fields := strings.Fields(line)
+			if len(fields) < 3 || (fields[2] != "+1" && !strings.HasPrefix(fields[2], "+1,")) {
+				return blobTopLines(blob)
+			}
+			inHunk = true
+			continue
+		}
+		if strings.HasPrefix(line, "@@ ") || strings.HasPrefix(line, "diff --git ") || len(lines) == markerLines {
+			break
+		}
+		if strings.HasPrefix(line, "+") || strings.HasPrefix(line, " ") {
+			lines = append(lines, line[1:])
+		}
+	}
+	if !inHunk {
+		return blobTopLines(blob)
+	}
+	return lines
+}
+
+// blobTops caches the first lines of blobs read by blobTopLines.
+var blobTops sync.Map
+
+// blobTopLines returns the first markerLines lines of the blob hash in the
+// current repository, or nothing if it is not there, as for a deleted file
+// or a diff against the work tree.
+func blobTopLines(hash string) []string {
+	if strings.Trim(hash, "0") == "" {
+		return nil
+	}
+	if lines, ok := blobTops.Load(hash); ok {
+		return lines.([]string)
+	}
+	var lines []string
+	if out, err := exec.Command("git", "cat-file", "blob", hash).Output(); err == nil {
+		lines = strings.SplitN(string(out), "\n", markerLines+1)
+		lines = lines[:min(len(lines), markerLines)]
+	}
+	blobTops.Store(hash, lines)
+	return lines
+}
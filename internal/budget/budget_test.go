@@ -0,0 +1,168 @@
+package budget_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/biswajitpain/gitter/internal/budget"
+)
+
+// fileDiff builds a minimal unified diff for path adding the given number of lines.
+func fileDiff(path string, lines int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n", path, path)
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", path, path)
+	fmt.Fprintf(&b, "@@ -0,0 +1,%d @@\n", lines)
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&b, "+line %d of %s with some padding text\n", i, path)
+	}
+	return b.String()
+}
+
+func TestPrepare_FitsBudget(t *testing.T) {
+	diff := fileDiff("main.go", 5) + fileDiff("README.md", 3)
+
+	result := budget.Prepare(context.Background(), diff, budget.Options{}, nil)
+	if result.Diff != diff {
+		t.Errorf("a diff within budget should be returned unchanged, got:\n%s", result.Diff)
+	}
+	if result.Changed() {
+		t.Errorf("Changed() = true for a diff within budget: %+v", result)
+	}
+}
+
+func TestPrepare_AbbreviatesGeneratedFiles(t *testing.T) {
+	diff := fileDiff("main.go", 5) + fileDiff("go.sum", 50) + fileDiff("vendor/x/y.go", 20) + fileDiff("api/api.pb.go", 20)
+
+	result := budget.Prepare(context.Background(), diff, budget.Options{}, nil)
+	want := []string{"go.sum", "vendor/x/y.go", "api/api.pb.go"}
+	if strings.Join(result.Abbreviated, ",") != strings.Join(want, ",") {
+		t.Errorf("Abbreviated = %v, want %v", result.Abbreviated, want)
+	}
+	if strings.Contains(result.Diff, "line 0 of go.sum") {
+		t.Errorf("lockfile content should not be in the prepared diff")
+	}
+	if !strings.Contains(result.Diff, "line 0 of main.go") {
+		t.Errorf("regular file content should be kept in the prepared diff")
+	}
+	if !strings.Contains(result.Diff, "- go.sum (+50 -0 lines)") {
+		t.Errorf("prepared diff should list abbreviated files with stats, got:\n%s", result.Diff)
+	}
+}
+
+func TestPrepare_SummarisesLargestFiles(t *testing.T) {
+	diff := fileDiff("small.go", 2) + fileDiff("big.go", 400) + fileDiff("medium.go", 30)
+
+	var mu sync.Mutex
+	var summarised []string
+	summarize := func(ctx context.Context, path string, fileDiff string) (string, error) {
+		mu.Lock()
+		summarised = append(summarised, path)
+		mu.Unlock()
+		return "rewrote the " + path + " module", nil
+	}
+
+	result := budget.Prepare(context.Background(), diff, budget.Options{MaxTokens: 1000}, summarize)
+	if len(result.Summarized) != 1 || result.Summarized[0] != "big.go" {
+		t.Fatalf("Summarized = %v, want [big.go]", result.Summarized)
+	}
+	if len(summarised) != 1 {
+		t.Errorf("summarizer called for %v, want only big.go", summarised)
+	}
+	if !strings.Contains(result.Diff, "big.go (+400 -0 lines): rewrote the big.go module") {
+		t.Errorf("prepared diff should contain the summary, got:\n%s", result.Diff)
+	}
+	if !strings.Contains(result.Diff, "line 0 of small.go") || !strings.Contains(result.Diff, "line 0 of medium.go") {
+		t.Errorf("smaller files should stay verbatim")
+	}
+	if budget.EstimateTokens(result.Diff) > 1000 {
+		t.Errorf("prepared diff is %d tokens, want at most 1000", budget.EstimateTokens(result.Diff))
+	}
+}
+
+func TestPrepare_OmitsFilesThatCannotBeSummarised(t *testing.T) {
+	diff := fileDiff("a.go", 300) + fileDiff("b.go", 300)
+	failing := func(ctx context.Context, path string, fileDiff string) (string, error) {
+		return "", errors.New("rate limited")
+	}
+
+	result := budget.Prepare(context.Background(), diff, budget.Options{MaxTokens: 500}, failing)
+	if len(result.Omitted) != 2 {
+		t.Fatalf("Omitted = %v, want both files", result.Omitted)
+	}
+	if !strings.Contains(result.Diff, "Omitted files:") {
+		t.Errorf("prepared diff should note omitted files, got:\n%s", result.Diff)
+	}
+	if budget.EstimateTokens(result.Diff) > 500 {
+		t.Errorf("prepared diff is %d tokens, want at most 500", budget.EstimateTokens(result.Diff))
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	tests := []struct {
+		path string
+		diff string
+		want bool
+	}{
+		{"go.sum", "", true},
+		{"web/package-lock.json", "", true},
+		{"third/node_modules/left-pad/index.js", "", true},
+		{"assets/app.min.js", "", true},
+		{"internal/gen/types.go", "@@ -0,0 +1,3 @@\n+// Code generated by stringer. DO NOT EDIT.\n+\n+package gen\n", true},
+		{"web/schema.js", "@@ -1,4 +1,4 @@\n /**\n- * @generated by v1\n+ * @generated by v2\n  */\n", true},
+		{"cmd/cr.go", "+func main() {}\n", false},
+		// Mentioning a marker below the top of a file does not make it generated.
+		{"internal/budget/budget.go", "@@ -0,0 +1,8 @@\n+package budget\n+\n+import \"strings\"\n+\n+// IsGenerated looks for markers.\n+//\n+// Comments may say @generated\n+// or Code generated ... DO NOT EDIT.\n", false},
+		{"internal/budget/budget.go", "@@ -300,2 +300,3 @@\n+\treturn strings.Contains(s, \"@generated\")\n", false},
+		{"docs/vendor.md", "", false},
+	}
+	for _, tt := range tests {
+		if got := budget.IsGenerated(tt.path, tt.diff); got != tt.want {
+			t.Errorf("IsGenerated(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIsGenerated_ReadsTopFromBlob(t *testing.T) {
+	repo := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", repo).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	var content strings.Builder
+	content.WriteString("// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n")
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&content, "var v%d = %d\n", i, i)
+	}
+	hash := exec.Command("git", "hash-object", "-w", "--stdin")
+	hash.Stdin = strings.NewReader(content.String())
+	out, err := hash.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := strings.TrimSpace(string(out))[:12]
+
+	// The change is far from the top, which the diff does not show.
+	fileDiff := "diff --git a/api/api.go b/api/api.go\nindex 0123456789ab.." + blob + " 100644\n--- a/api/api.go\n+++ b/api/api.go\n" +
+		"@@ -40,3 +40,3 @@\n var v36 = 36\n-var v37 = 0\n+var v37 = 37\n"
+	if !budget.IsGenerated("api/api.go", fileDiff) {
+		t.Error("IsGenerated() = false for a change below the marker of a generated file")
+	}
+	if budget.IsGenerated("api/api.go", strings.Replace(fileDiff, blob, "fedcba987654", 1)) {
+		t.Error("IsGenerated() = true for a blob that does not exist")
+	}
+}
@@ -0,0 +1,309 @@
+// Package changelog builds release notes from conventional commit history
+// and renders them as Markdown, JSON or Keep a Changelog.
+package changelog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/biswajitpain/gitter/internal/conventional"
+)
+
+// sections lists the commit types in the order they appear in a changelog,
+// with their headings.
+var sections = []struct {
+	Type  string
+	Title string
+}{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance Improvements"},
+	{"refactor", "Code Refactoring"},
+	{"revert", "Reverts"},
+	{"docs", "Documentation"},
+	{"style", "Styles"},
+	{"test", "Tests"},
+	{"build", "Build System"},
+	{"ci", "Continuous Integration"},
+	{"chore", "Chores"},
+}
+
+// otherTitle is the heading for commits that are not conventional commits.
+const otherTitle = "Other Changes"
+
+// Commit is a commit as read from git.
+type Commit struct {
+	Hash    string
+	Message string
+}
+
+// Entry is a single changelog line.
+type Entry struct {
+	Hash        string `json:"hash"`
+	URL         string `json:"url,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Scope       string `json:"scope,omitempty"`
+	Description string `json:"description"`
+	Breaking    bool   `json:"breaking,omitempty"`
+	// BreakingNote describes the breaking change, from the BREAKING CHANGE
+	// footer or the description.
+	BreakingNote string `json:"breaking_note,omitempty"`
+}
+
+// ShortHash returns the first seven characters of the commit hash.
+func (e Entry) ShortHash() string {
+	if len(e.Hash) > 7 {
+		return e.Hash[:7]
+	}
+	return e.Hash
+}
+
+// Group is the entries of one commit type, sorted by scope.
+type Group struct {
+	Type    string  `json:"type"`
+	Title   string  `json:"title"`
+	Entries []Entry `json:"entries"`
+}
+
+// Changelog is the release notes for one version.
+type Changelog struct {
+	// Version is the heading, e.g. "v1.2.0" or "Unreleased".
+	Version string `json:"version"`
+	// Date is the release date in YYYY-MM-DD form, if known.
+	Date string `json:"date,omitempty"`
+	// Breaking lists every breaking change; the entries also appear in
+	// their type's group.
+	Breaking []Entry `json:"breaking,omitempty"`
+	Groups   []Group `json:"groups"`
+}
+
+// Options configures Build.
+type Options struct {
+	Version string
+	Date    string
+	// CommitURL is the prefix commit hashes are appended to for links,
+	// e.g. "https://github.com/owner/repo/commit/". No links when empty.
+	CommitURL string
+}
+
+// Build groups commits into a changelog. Conventional commits are grouped
+// by type and sorted by scope; other commits go under "Other Changes".
+// Autosquash commits (fixup!, squash!, amend!) are left out.
+func Build(commits []Commit, opts Options) *Changelog {
+	c := &Changelog{Version: opts.Version, Date: opts.Date}
+	byType := map[string][]Entry{}
+
+	for _, commit := range commits {
+		message := strings.TrimSpace(commit.Message)
+		if strings.HasPrefix(message, "fixup! ") || strings.HasPrefix(message, "squash! ") || strings.HasPrefix(message, "amend! ") {
+			continue
+		}
+
+		entry := Entry{Hash: commit.Hash}
+		if opts.CommitURL != "" {
+			entry.URL = opts.CommitURL + commit.Hash
+		}
+
+		parsed, err := conventional.Parse(message)
+		if err != nil {
+			subject, _, _ := strings.Cut(message, "\n")
+			entry.Description = subject
+			byType[""] = append(byType[""], entry)
+			continue
+		}
+
+		entry.Type = parsed.Type
+		entry.Scope = parsed.Scope
+		entry.Description = parsed.Description
+		entry.Breaking = parsed.Breaking
+		entry.BreakingNote = parsed.BreakingNote
+		if entry.Breaking {
+			c.Breaking = append(c.Breaking, entry)
+		}
+		byType[entry.Type] = append(byType[entry.Type], entry)
+	}
+
+	known := map[string]bool{}
+	for _, section := range sections {
+		known[section.Type] = true
+		if entries := byType[section.Type]; len(entries) > 0 {
+			c.Groups = append(c.Groups, newGroup(section.Type, section.Title, entries))
+		}
+	}
+
+	// Custom types follow the standard ones, alphabetically.
+	var custom []string
+	for commitType := range byType {
+		if commitType != "" && !known[commitType] {
+			custom = append(custom, commitType)
+		}
+	}
+	sort.Strings(custom)
+	for _, commitType := range custom {
+		c.Groups = append(c.Groups, newGroup(commitType, commitType, byType[commitType]))
+	}
+
+	if entries := byType[""]; len(entries) > 0 {
+		c.Groups = append(c.Groups, newGroup("", otherTitle, entries))
+	}
+	return c
+}
+
+// newGroup sorts entries by scope, keeping history order within a scope.
+// Entries without a scope come first.
+func newGroup(commitType, title string, entries []Entry) Group {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Scope < entries[j].Scope
+	})
+	return Group{Type: commitType, Title: title, Entries: entries}
+}
+
+// Texts returns pointers to every piece of user-facing text in the
+// changelog: the breaking change notes and each entry's description. It
+// lets callers rewrite entries, for example into plainer language.
+func (c *Changelog) Texts() []*string {
+	var texts []*string
+	for i := range c.Breaking {
+		texts = append(texts, &c.Breaking[i].BreakingNote)
+	}
+	for i := range c.Groups {
+		for j := range c.Groups[i].Entries {
+			texts = append(texts, &c.Groups[i].Entries[j].Description)
+		}
+	}
+	return texts
+}
+
+// Empty reports whether the changelog has no entries.
+func (c *Changelog) Empty() bool {
+	return len(c.Groups) == 0
+}
+
+// heading returns the version heading with the date, if any.
+func (c *Changelog) heading() string {
+	if c.Date == "" {
+		return c.Version
+	}
+	return fmt.Sprintf("%s (%s)", c.Version, c.Date)
+}
+
+// Markdown renders the changelog in the conventional-changelog style.
+func (c *Changelog) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n", c.heading())
+
+	if len(c.Breaking) > 0 {
+		b.WriteString("\n### BREAKING CHANGES\n\n")
+		for _, entry := range c.Breaking {
+			b.WriteString("- " + formatEntry(entry, entry.BreakingNote) + "\n")
+		}
+	}
+	for _, group := range c.Groups {
+		fmt.Fprintf(&b, "\n### %s\n\n", group.Title)
+		for _, entry := range group.Entries {
+			b.WriteString("- " + formatEntry(entry, entry.Description) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// keepAChangelogSections maps commit types to Keep a Changelog headings.
+// Types not listed are reported under "Changed".
+var keepAChangelogSections = map[string]string{
+	"feat": "Added",
+	"fix":  "Fixed",
+}
+
+// KeepAChangelog renders the changelog in the https://keepachangelog.com
+// format. Breaking changes are listed first under "Changed".
+func (c *Changelog) KeepAChangelog() string {
+	order := []string{"Added", "Changed", "Fixed"}
+	items := map[string][]string{}
+
+	for _, entry := range c.Breaking {
+		items["Changed"] = append(items["Changed"], "**BREAKING:** "+formatEntry(entry, entry.BreakingNote))
+	}
+	for _, group := range c.Groups {
+		section, ok := keepAChangelogSections[group.Type]
+		if !ok {
+			section = "Changed"
+		}
+		for _, entry := range group.Entries {
+			items[section] = append(items[section], formatEntry(entry, entry.Description))
+		}
+	}
+
+	var b strings.Builder
+	version := "[" + c.Version + "]"
+	if c.Date != "" {
+		version += " - " + c.Date
+	}
+	fmt.Fprintf(&b, "## %s\n", version)
+	for _, section := range order {
+		if len(items[section]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", section)
+		for _, item := range items[section] {
+			b.WriteString("- " + item + "\n")
+		}
+	}
+	return b.String()
+}
+
+// JSON renders the changelog as indented JSON.
+func (c *Changelog) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// formatEntry renders one Markdown list item body: the bold scope, the
+// text and the (linked) short hash.
+func formatEntry(entry Entry, text string) string {
+	var b strings.Builder
+	if entry.Scope != "" {
+		fmt.Fprintf(&b, "**%s:** ", entry.Scope)
+	}
+	b.WriteString(text)
+	if entry.URL != "" {
+		fmt.Fprintf(&b, " ([%s](%s))", entry.ShortHash(), entry.URL)
+	} else {
+		fmt.Fprintf(&b, " (%s)", entry.ShortHash())
+	}
+	return b.String()
+}
+
+// CommitURL returns the web URL prefix for commits of the repository at
+// remoteURL, e.g. "git@github.com:owner/repo.git" becomes
+// "https://github.com/owner/repo/commit/". It returns an empty string for
+// remotes that are not hosted on the web, such as local paths.
+func CommitURL(remoteURL string) string {
+	remoteURL = strings.TrimSpace(remoteURL)
+	var host, path string
+
+	if u, err := url.Parse(remoteURL); err == nil && u.Host != "" {
+		switch u.Scheme {
+		case "http", "https", "ssh", "git":
+		default:
+			return ""
+		}
+		host, path = u.Hostname(), u.Path
+	} else if at := strings.Index(remoteURL, "@"); at >= 0 && strings.Contains(remoteURL[at:], ":") {
+		// scp-like syntax: user@host:owner/repo.git
+		host, path, _ = strings.Cut(remoteURL[at+1:], ":")
+	} else {
+		return ""
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || path == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://%s/%s/commit/", host, path)
+}
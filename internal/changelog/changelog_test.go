@@ -0,0 +1,120 @@
+package changelog_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/biswajitpain/gitter/internal/changelog"
+)
+
+var history = []changelog.Commit{
+	{Hash: "aaaaaaa1111", Message: "feat(cli): add lint command"},
+	{Hash: "bbbbbbb2222", Message: "fix: handle empty diff"},
+	{Hash: "ccccccc3333", Message: "feat(api)!: drop v1 endpoints\n\nBREAKING CHANGE: the v1 API is gone"},
+	{Hash: "ddddddd4444", Message: "Update README"},
+	{Hash: "eeeeeee5555", Message: "fixup! fix: handle empty diff"},
+	{Hash: "fffffff6666", Message: "feat: support azure"},
+}
+
+func TestBuild_Markdown(t *testing.T) {
+	c := changelog.Build(history, changelog.Options{
+		Version:   "v1.2.0",
+		Date:      "2026-10-17",
+		CommitURL: "https://github.com/o/r/commit/",
+	})
+
+	want := `## v1.2.0 (2026-10-17)
+
+### BREAKING CHANGES
+
+- **api:** the v1 API is gone ([ccccccc](https://github.com/o/r/commit/ccccccc3333))
+
+### Features
+
+- support azure ([fffffff](https://github.com/o/r/commit/fffffff6666))
+- **api:** drop v1 endpoints ([ccccccc](https://github.com/o/r/commit/ccccccc3333))
+- **cli:** add lint command ([aaaaaaa](https://github.com/o/r/commit/aaaaaaa1111))
+
+### Bug Fixes
+
+- handle empty diff ([bbbbbbb](https://github.com/o/r/commit/bbbbbbb2222))
+
+### Other Changes
+
+- Update README ([ddddddd](https://github.com/o/r/commit/ddddddd4444))
+`
+	if got := c.Markdown(); got != want {
+		t.Errorf("Markdown() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBuild_KeepAChangelog(t *testing.T) {
+	c := changelog.Build(history, changelog.Options{Version: "Unreleased"})
+
+	want := `## [Unreleased]
+
+### Added
+
+- support azure (fffffff)
+- **api:** drop v1 endpoints (ccccccc)
+- **cli:** add lint command (aaaaaaa)
+
+### Changed
+
+- **BREAKING:** **api:** the v1 API is gone (ccccccc)
+- Update README (ddddddd)
+
+### Fixed
+
+- handle empty diff (bbbbbbb)
+`
+	if got := c.KeepAChangelog(); got != want {
+		t.Errorf("KeepAChangelog() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBuild_JSON(t *testing.T) {
+	c := changelog.Build(history, changelog.Options{Version: "v1.2.0"})
+	data, err := c.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error: %v", err)
+	}
+
+	var decoded changelog.Changelog
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("JSON() produced invalid JSON: %v\n%s", err, data)
+	}
+	if len(decoded.Breaking) != 1 || decoded.Breaking[0].Scope != "api" {
+		t.Errorf("Breaking = %+v, want the api change", decoded.Breaking)
+	}
+	if len(decoded.Groups) != 3 || decoded.Groups[0].Type != "feat" {
+		t.Errorf("Groups = %+v, want feat, fix and other groups", decoded.Groups)
+	}
+}
+
+func TestTexts(t *testing.T) {
+	c := changelog.Build(history, changelog.Options{Version: "v1.2.0"})
+	for _, text := range c.Texts() {
+		*text = strings.ToUpper(*text)
+	}
+	if !strings.Contains(c.Markdown(), "**api:** THE V1 API IS GONE") || !strings.Contains(c.Markdown(), "- HANDLE EMPTY DIFF") {
+		t.Errorf("rewriting Texts() should change the rendered changelog, got:\n%s", c.Markdown())
+	}
+}
+
+func TestCommitURL(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:owner/repo.git":          "https://github.com/owner/repo/commit/",
+		"https://github.com/owner/repo.git":      "https://github.com/owner/repo/commit/",
+		"https://gitlab.com/group/sub/repo":      "https://gitlab.com/group/sub/repo/commit/",
+		"ssh://git@github.com:22/owner/repo.git": "https://github.com/owner/repo/commit/",
+		"/srv/git/repo.git":                      "",
+		"file:///srv/git/repo.git":               "",
+	}
+	for remote, want := range tests {
+		if got := changelog.CommitURL(remote); got != want {
+			t.Errorf("CommitURL(%q) = %q, want %q", remote, got, want)
+		}
+	}
+}
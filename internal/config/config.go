@@ -11,6 +11,41 @@ import (
 type Config struct {
 	Provider string `json:"provider"`
 	APIKey   string `json:"api_key"`
+	// APIKeyCommand is a shell command printing the API key, such as
+	// "pass show openai". It is run when no API key is set.
+	APIKeyCommand string `json:"api_key_command,omitempty"`
+	// APIKeyStore is KeyringStore when the API key is kept in the system
+	// keyring under the provider's name.
+	APIKeyStore string `json:"api_key_store,omitempty"`
+	// Model overrides the provider's default model when set.
+	Model string `json:"model,omitempty"`
+	// BaseURL overrides the provider's endpoint, e.g. a local Ollama server.
+	BaseURL string `json:"base_url,omitempty"`
+	// KeepAlive is passed to Ollama to control how long the model stays loaded.
+	KeepAlive string `json:"keep_alive,omitempty"`
+	// Headers are extra HTTP headers sent with every LLM request.
+	Headers map[string]string `json:"headers,omitempty"`
+	// APIVersion is sent as the api-version query parameter (Azure OpenAI).
+	APIVersion string `json:"api_version,omitempty"`
+	// MaxDiffTokens is the approximate token budget for diffs sent to the LLM.
+	// Larger diffs are abbreviated and summarised per file. Zero uses the default.
+	MaxDiffTokens int `json:"max_diff_tokens,omitempty"`
+	// StandupRepos are extra repositories 'gitter standup' reports on.
+	StandupRepos []string `json:"standup_repos,omitempty"`
+	// RedactPatterns are extra regular expressions for secrets to mask
+	// before diffs are sent to the LLM, in addition to the built-in ones.
+	RedactPatterns []string `json:"redact_patterns,omitempty"`
+	// LLMInclude, if set, limits the files whose diffs are sent to the LLM
+	// to those matching these globs (gitignore syntax).
+	LLMInclude []string `json:"llm_include,omitempty"`
+	// LLMExclude are globs (gitignore syntax) of files whose diffs are never
+	// sent to the LLM, in addition to those listed in .gitterignore.
+	LLMExclude []string `json:"llm_exclude,omitempty"`
+	// AuditLog records every LLM request in the audit log.
+	AuditLog bool `json:"audit_log,omitempty"`
+	// AuditFullPrompts records the prompts in full rather than only their
+	// hash. It has no effect unless AuditLog is set.
+	AuditFullPrompts bool `json:"audit_full_prompts,omitempty"`
 }
 
 // GetConfigPath returns the path to the configuration file.
@@ -27,8 +62,10 @@ func GetConfigPath() (string, error) {
 	return filepath.Join(configDir, "config.json"), nil
 }
 
-// LoadConfig loads the configuration from the file.
-func LoadConfig() (Config, error) {
+// LoadGlobalConfig loads the configuration from the global file only,
+// without the per-repository and environment overrides LoadConfig applies.
+// It is what 'gitter config' edits.
+func LoadGlobalConfig() (Config, error) {
 	var config Config
 	path, err := GetConfigPath()
 	if err != nil {
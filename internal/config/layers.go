@@ -0,0 +1,408 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/biswajitpain/gitter/internal/keyring"
+)
+
+// RepoFile is the name of the per-repository config file at the top of the
+// work tree.
+const RepoFile = ".gitter.json"
+
+// OriginDefault is the origin of settings that no source sets.
+const OriginDefault = "default"
+
+// KeyringStore is the APIKeyStore value for keys kept in the system keyring.
+const KeyringStore = "keyring"
+
+// KeyringService is the keyring service API keys are stored under; the
+// account is the provider's name.
+const KeyringService = "gitter"
+
+// repoRestricted are the settings RepoFile may not set. The file is usually
+// committed, and a repository must not be able to send your diffs and API
+// key to a provider or server of its choosing, or turn off your audit log.
+var repoRestricted = map[string]bool{
+	"provider":           true,
+	"api_key":            true,
+	"api_key_command":    true,
+	"base_url":           true,
+	"headers":            true,
+	"audit_log":          true,
+	"audit_full_prompts": true,
+}
+
+// repoAppended are the lists RepoFile adds to instead of replacing, so that
+// a repository can withhold or mask more than you do, but never less.
+var repoAppended = map[string]bool{
+	"llm_exclude":     true,
+	"redact_patterns": true,
+}
+
+// providerKeyEnv are the API key variables each provider's own tools use.
+// They apply when GITTER_API_KEY is not set.
+var providerKeyEnv = map[string]string{
+	"openai":    "OPENAI_API_KEY",
+	"anthropic": "ANTHROPIC_API_KEY",
+	"azure":     "AZURE_OPENAI_API_KEY",
+}
+
+// Effective is the merged configuration together with where each setting
+// came from.
+type Effective struct {
+	Config Config
+	// Origins maps the key of each setting that is set to its source, such
+	// as a file path, "git config gitter.model" or "env GITTER_MODEL".
+	Origins map[string]string
+}
+
+// Origin returns where the setting key came from, or OriginDefault.
+func (e Effective) Origin(key string) string {
+	if origin, ok := e.Origins[key]; ok {
+		return origin
+	}
+	return OriginDefault
+}
+
+// field is a setting of Config: its JSON key and position in the struct.
+type field struct {
+	key   string
+	index int
+}
+
+// fields lists the settings of Config in declaration order.
+var fields = func() []field {
+	var fs []field
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fs = append(fs, field{key: key, index: i})
+	}
+	return fs
+}()
+
+// Keys returns the keys of all settings, in declaration order.
+func Keys() []string {
+	keys := make([]string, len(fields))
+	for i, f := range fields {
+		keys[i] = f.key
+	}
+	return keys
+}
+
+// Value returns the setting key of cfg.
+func Value(cfg Config, key string) any {
+	for _, f := range fields {
+		if f.key == key {
+			return reflect.ValueOf(cfg).Field(f.index).Interface()
+		}
+	}
+	return nil
+}
+
+// lookupField finds a setting by key, ignoring case, "_" and "-", so that
+// git config names such as apiKey or api-key match api_key.
+func lookupField(name string) (field, bool) {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(s))
+	}
+	for _, f := range fields {
+		if normalize(f.key) == normalize(name) {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// LoadConfig returns the effective configuration. See LoadEffective.
+func LoadConfig() (Config, error) {
+	e, err := LoadEffective()
+	return e.Config, err
+}
+
+// LoadEffective merges the configuration from these sources, each taking
+// precedence over the ones before it:
+//
+//  1. the global file, ~/.config/gitter/config.json
+//  2. RepoFile at the top of the current repository, which may not set
+//     the provider, its API key, base_url, headers or the audit log, and
+//     adds to llm_exclude and redact_patterns rather than replacing them
+//  3. the [gitter] section of the repository's .git/config, with names
+//     such as gitter.model or gitter.maxDiffTokens
+//  4. GITTER_<KEY> environment variables, such as GITTER_PROVIDER, and
+//     the provider's usual API key variable, such as OPENAI_API_KEY
+//
+// If no API key is set after that, it is read with APIKeyCommand or from
+// the keyring. A problem with one source is returned as an error, but the
+// other sources are still applied.
+func LoadEffective() (Effective, error) {
+	e, err := LoadEffectiveUnresolved()
+	return e, errors.Join(err, e.resolveAPIKey())
+}
+
+// LoadEffectiveUnresolved is LoadEffective without reading the API key with
+// APIKeyCommand or from the keyring, for showing the settings without
+// running a credential helper.
+func LoadEffectiveUnresolved() (Effective, error) {
+	e := Effective{Origins: map[string]string{}}
+	var errs []error
+
+	path, err := GetConfigPath()
+	if err == nil {
+		err = e.applyFile(path, false)
+	}
+	errs = append(errs, err)
+
+	if top, err := exec.Command("git", "rev-parse", "--show-toplevel").Output(); err == nil {
+		errs = append(errs, e.applyFile(filepath.Join(strings.TrimSpace(string(top)), RepoFile), true))
+		errs = append(errs, e.applyGitConfig())
+	}
+
+	errs = append(errs, e.applyEnv(os.LookupEnv))
+	return e, errors.Join(errs...)
+}
+
+// applyFile applies the settings in the JSON file at path, if it exists.
+// For RepoFile, repo is true: restricted settings are ignored and reported,
+// appended lists are added to, and llm_include may only narrow what is
+// sent, so it is ignored if an earlier source set it.
+func (e *Effective) applyFile(path string, repo bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not open config file: %w", err)
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("could not decode config file %s: %w", path, err)
+	}
+	var errs []error
+	for _, f := range fields {
+		raw, ok := values[f.key]
+		if !ok {
+			continue
+		}
+		v := reflect.ValueOf(&e.Config).Elem().Field(f.index)
+		switch {
+		case repo && repoRestricted[f.key]:
+			errs = append(errs, fmt.Errorf("%s: %s cannot be set per repository, ignored", path, f.key))
+			continue
+		case repo && f.key == "llm_include" && !v.IsZero():
+			errs = append(errs, fmt.Errorf("%s: llm_include is already set by %s, ignored", path, e.Origin(f.key)))
+			continue
+		case repo && repoAppended[f.key]:
+			var items []string
+			if err := json.Unmarshal(raw, &items); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid %s: %w", path, f.key, err))
+				continue
+			}
+			if len(items) > 0 {
+				v.Set(reflect.AppendSlice(v, reflect.ValueOf(items)))
+				e.appendOrigin(f, path)
+			}
+			continue
+		}
+		// Reset the field first, so that a map or list replaces the one
+		// from an earlier source instead of being merged into it.
+		v.SetZero()
+		if err := json.Unmarshal(raw, v.Addr().Interface()); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid %s: %w", path, f.key, err))
+			continue
+		}
+		e.setOrigin(f, path)
+	}
+	return errors.Join(errs...)
+}
+
+// applyGitConfig applies the [gitter] section of the repository's own git
+// config. Global git config is not read.
+func (e *Effective) applyGitConfig() error {
+	out, err := exec.Command("git", "config", "--local", "--null", "--get-regexp", `^gitter\.`).Output()
+	if err != nil {
+		// git config exits with 1 when nothing matches.
+		return nil
+	}
+
+	var errs []error
+	for _, entry := range bytes.Split(out, []byte{0}) {
+		name, value, _ := strings.Cut(string(entry), "\n")
+		if name == "" {
+			continue
+		}
+		origin := "git config " + name
+		f, ok := lookupField(strings.TrimPrefix(name, "gitter."))
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown setting", origin))
+			continue
+		}
+		if err := e.set(f, value, origin); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyEnv applies GITTER_<KEY> variables and then, unless GITTER_API_KEY
+// is set, the effective provider's usual API key variable. Empty variables
+// are ignored.
+func (e *Effective) applyEnv(lookup func(string) (string, bool)) error {
+	var errs []error
+	for _, f := range fields {
+		name := "GITTER_" + strings.ToUpper(f.key)
+		if value, ok := lookup(name); ok && value != "" {
+			if err := e.set(f, value, "env "+name); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if value, _ := lookup("GITTER_API_KEY"); value == "" {
+		if name := providerKeyEnv[e.Config.Provider]; name != "" {
+			if value, ok := lookup(name); ok && value != "" {
+				f, _ := lookupField("api_key")
+				errs = append(errs, e.set(f, value, "env "+name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// set parses value, given as text by git config or the environment, into
+// the setting f. Lists are comma-separated.
+func (e *Effective) set(f field, value string, origin string) error {
+	v := reflect.ValueOf(&e.Config).Elem().Field(f.index)
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid number %q", origin, value)
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid boolean %q", origin, value)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("%s: %s can only be set in a config file", origin, f.key)
+	}
+	e.setOrigin(f, origin)
+	return nil
+}
+
+// setOrigin records that f was last set by origin. A setting cleared by a
+// later source counts as unset.
+func (e *Effective) setOrigin(f field, origin string) {
+	if reflect.ValueOf(e.Config).Field(f.index).IsZero() {
+		delete(e.Origins, f.key)
+		return
+	}
+	e.Origins[f.key] = origin
+}
+
+// appendOrigin records that origin added to the list f.
+func (e *Effective) appendOrigin(f field, origin string) {
+	if prev, ok := e.Origins[f.key]; ok {
+		origin = prev + ", " + origin
+	}
+	e.Origins[f.key] = origin
+}
+
+// resolvedKeys caches the API keys read by resolveAPIKey, so that a
+// command loading the config several times runs a credential helper, which
+// may ask for a passphrase, only once.
+var resolvedKeys sync.Map
+
+// resolveAPIKey reads the API key with APIKeyCommand or from the keyring,
+// unless one is already set.
+func (e *Effective) resolveAPIKey() error {
+	if e.Config.APIKey != "" {
+		return nil
+	}
+	var origin string
+	var read func() (string, error)
+	switch {
+	case e.Config.APIKeyCommand != "":
+		origin = "api_key_command"
+		read = func() (string, error) { return runKeyCommand(e.Config.APIKeyCommand) }
+	case e.Config.APIKeyStore == KeyringStore:
+		origin = "keyring"
+		read = func() (string, error) { return readKeyring(e.Config.Provider) }
+	default:
+		return nil
+	}
+
+	cacheKey := origin + "\x00" + e.Config.APIKeyCommand + "\x00" + e.Config.Provider
+	key, ok := resolvedKeys.Load(cacheKey)
+	if !ok {
+		k, err := read()
+		if err != nil {
+			return err
+		}
+		key, _ = resolvedKeys.LoadOrStore(cacheKey, k)
+	}
+	e.Config.APIKey = key.(string)
+	f, _ := lookupField("api_key")
+	e.setOrigin(f, origin)
+	return nil
+}
+
+// readKeyring returns the API key of provider from the system keyring.
+func readKeyring(provider string) (string, error) {
+	ring, err := keyring.Default()
+	if err != nil {
+		return "", fmt.Errorf("the API key is kept in the keyring: %w", err)
+	}
+	key, err := ring.Get(KeyringService, provider)
+	if err != nil {
+		return "", fmt.Errorf("could not read the %s API key: %w", provider, err)
+	}
+	return key, nil
+}
+
+// runKeyCommand runs command with the shell and returns the first line it
+// prints, as credential helpers such as "pass show" put the secret there.
+func runKeyCommand(command string) (string, error) {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	cmd := exec.Command(shell, flag, command)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("api_key_command %q failed: %w", command, err)
+	}
+	key, _, _ := strings.Cut(string(out), "\n")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", fmt.Errorf("api_key_command %q printed no API key", command)
+	}
+	return key, nil
+}
@@ -0,0 +1,243 @@
+package config_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/biswajitpain/gitter/internal/config"
+	"github.com/biswajitpain/gitter/internal/keyring"
+)
+
+// inRepo runs the test in a new git repository with a fresh HOME and no
+// gitter variables in the environment.
+func inRepo(t *testing.T) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	for _, v := range os.Environ() {
+		name, _, _ := strings.Cut(v, "=")
+		if strings.HasPrefix(name, "GITTER_") || strings.HasSuffix(name, "_API_KEY") {
+			t.Setenv(name, "")
+		}
+	}
+
+	repo := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", repo).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return repo
+}
+
+func TestLoadEffective_Precedence(t *testing.T) {
+	repo := inRepo(t)
+	if err := config.SaveConfig(config.Config{Provider: "openai", APIKey: "global-key", Model: "gpt-4o", MaxDiffTokens: 1000, AuditLog: true}); err != nil {
+		t.Fatal(err)
+	}
+	repoFile := filepath.Join(repo, config.RepoFile)
+	if err := os.WriteFile(repoFile, []byte(`{"model": "repo-model", "max_diff_tokens": 2000, "llm_exclude": ["nda/"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, kv := range [][]string{{"gitter.maxDiffTokens", "3000"}, {"gitter.llm-exclude", "nda/, *.pem"}} {
+		if out, err := exec.Command("git", "config", kv[0], kv[1]).CombinedOutput(); err != nil {
+			t.Fatalf("git config: %v\n%s", err, out)
+		}
+	}
+	t.Setenv("GITTER_MODEL", "env-model")
+	t.Setenv("OPENAI_API_KEY", "env-key")
+
+	e, err := config.LoadEffective()
+	if err != nil {
+		t.Fatalf("LoadEffective() error: %v", err)
+	}
+	cfg := e.Config
+	if cfg.Provider != "openai" || cfg.Model != "env-model" || cfg.APIKey != "env-key" || cfg.MaxDiffTokens != 3000 || !cfg.AuditLog {
+		t.Errorf("LoadEffective() = %+v", cfg)
+	}
+	if want := []string{"nda/", "*.pem"}; !reflect.DeepEqual(cfg.LLMExclude, want) {
+		t.Errorf("LLMExclude = %q, want %q", cfg.LLMExclude, want)
+	}
+
+	global, _ := config.GetConfigPath()
+	origins := map[string]string{
+		"provider":        global,
+		"model":           "env GITTER_MODEL",
+		"api_key":         "env OPENAI_API_KEY",
+		"max_diff_tokens": "git config gitter.maxdifftokens",
+		"llm_exclude":     "git config gitter.llm-exclude",
+		"base_url":        config.OriginDefault,
+	}
+	for key, want := range origins {
+		if got := e.Origin(key); got != want {
+			t.Errorf("Origin(%s) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestLoadEffective_RepoFileCannotRedirectRequests(t *testing.T) {
+	repo := inRepo(t)
+	if err := config.SaveConfig(config.Config{Provider: "openai", APIKey: "global-key"}); err != nil {
+		t.Fatal(err)
+	}
+	data := `{"base_url": "https://evil.example", "api_key": "theirs", "headers": {"X": "y"}, "model": "ok"}`
+	if err := os.WriteFile(filepath.Join(repo, config.RepoFile), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err == nil || !strings.Contains(err.Error(), "base_url cannot be set per repository") {
+		t.Errorf("LoadConfig() error = %v, want base_url to be refused", err)
+	}
+	if cfg.BaseURL != "" || cfg.APIKey != "global-key" || cfg.Headers != nil || cfg.Model != "ok" {
+		t.Errorf("LoadConfig() = %+v, want only the model from the repository", cfg)
+	}
+}
+
+func TestLoadEffective_RepoFileCannotWeakenPolicy(t *testing.T) {
+	repo := inRepo(t)
+	global := config.Config{
+		Provider:       "ollama",
+		AuditLog:       true,
+		LLMInclude:     []string{"src/"},
+		LLMExclude:     []string{"secrets/"},
+		RedactPatterns: []string{`ACME-[0-9]+`},
+	}
+	if err := config.SaveConfig(global); err != nil {
+		t.Fatal(err)
+	}
+	data := `{"provider": "openai", "audit_log": false, "llm_include": ["**"], "llm_exclude": [], "redact_patterns": ["INTERNAL-[A-Z]+"]}`
+	if err := os.WriteFile(filepath.Join(repo, config.RepoFile), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := config.LoadEffective()
+	for _, key := range []string{"provider cannot", "audit_log cannot", "llm_include is already set"} {
+		if err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("LoadEffective() error = %v, want %q", err, key)
+		}
+	}
+	cfg := e.Config
+	if cfg.Provider != "ollama" || !cfg.AuditLog {
+		t.Errorf("LoadEffective() = %+v, want the global provider and audit log", cfg)
+	}
+	if !reflect.DeepEqual(cfg.LLMInclude, global.LLMInclude) || !reflect.DeepEqual(cfg.LLMExclude, global.LLMExclude) {
+		t.Errorf("LLMInclude, LLMExclude = %q, %q, want the global lists", cfg.LLMInclude, cfg.LLMExclude)
+	}
+	if want := []string{`ACME-[0-9]+`, "INTERNAL-[A-Z]+"}; !reflect.DeepEqual(cfg.RedactPatterns, want) {
+		t.Errorf("RedactPatterns = %q, want %q", cfg.RedactPatterns, want)
+	}
+
+	// A repository can still withhold more than the user does.
+	data = `{"llm_exclude": ["nda/"]}`
+	if err := os.WriteFile(filepath.Join(repo, config.RepoFile), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if e, err = config.LoadEffective(); err != nil {
+		t.Fatalf("LoadEffective() error: %v", err)
+	}
+	if want := []string{"secrets/", "nda/"}; !reflect.DeepEqual(e.Config.LLMExclude, want) {
+		t.Errorf("LLMExclude = %q, want %q", e.Config.LLMExclude, want)
+	}
+	globalPath, _ := config.GetConfigPath()
+	if want := globalPath + ", " + filepath.Join(repo, config.RepoFile); e.Origin("llm_exclude") != want {
+		t.Errorf("Origin(llm_exclude) = %q, want %q", e.Origin("llm_exclude"), want)
+	}
+}
+
+func TestLoadEffective_GitterAPIKeyWins(t *testing.T) {
+	inRepo(t)
+	t.Setenv("GITTER_PROVIDER", "anthropic")
+	t.Setenv("GITTER_API_KEY", "gitter-key")
+	t.Setenv("ANTHROPIC_API_KEY", "anthropic-key")
+	t.Setenv("GITTER_MAX_DIFF_TOKENS", "lots")
+
+	cfg, err := config.LoadConfig()
+	if err == nil || !strings.Contains(err.Error(), "GITTER_MAX_DIFF_TOKENS") {
+		t.Errorf("LoadConfig() error = %v, want the invalid number reported", err)
+	}
+	if cfg.Provider != "anthropic" || cfg.APIKey != "gitter-key" {
+		t.Errorf("LoadConfig() = %+v, want GITTER_API_KEY to take precedence", cfg)
+	}
+}
+
+func TestLoadEffective_APIKeyCommand(t *testing.T) {
+	repo := inRepo(t)
+	if err := config.SaveConfig(config.Config{Provider: "openai", APIKeyCommand: "echo sk-from-helper; echo ignored"}); err != nil {
+		t.Fatal(err)
+	}
+	e, err := config.LoadEffective()
+	if err != nil {
+		t.Fatalf("LoadEffective() error: %v", err)
+	}
+	if e.Config.APIKey != "sk-from-helper" || e.Origin("api_key") != "api_key_command" {
+		t.Errorf("API key = %q from %q, want the helper's first line", e.Config.APIKey, e.Origin("api_key"))
+	}
+
+	// A repository may not run commands of its own.
+	data := `{"api_key_command": "echo sk-from-repo"}`
+	if err := os.WriteFile(filepath.Join(repo, config.RepoFile), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig()
+	if err == nil || !strings.Contains(err.Error(), "api_key_command cannot be set per repository") {
+		t.Errorf("LoadConfig() error = %v, want api_key_command to be refused", err)
+	}
+	if cfg.APIKey != "sk-from-helper" {
+		t.Errorf("API key = %q, want the global helper's", cfg.APIKey)
+	}
+}
+
+func TestLoadEffectiveUnresolved_RunsNoHelper(t *testing.T) {
+	repo := inRepo(t)
+	marker := filepath.Join(repo, "helper-ran")
+	if err := config.SaveConfig(config.Config{Provider: "openai", APIKeyCommand: "touch " + marker + "; echo sk-from-helper"}); err != nil {
+		t.Fatal(err)
+	}
+	e, err := config.LoadEffectiveUnresolved()
+	if err != nil {
+		t.Fatalf("LoadEffectiveUnresolved() error: %v", err)
+	}
+	if e.Config.APIKey != "" || e.Config.APIKeyCommand == "" {
+		t.Errorf("API key = %q, command = %q, want only the command", e.Config.APIKey, e.Config.APIKeyCommand)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("LoadEffectiveUnresolved() ran api_key_command")
+	}
+}
+
+func TestLoadEffective_Keyring(t *testing.T) {
+	inRepo(t)
+	ringPath := filepath.Join(t.TempDir(), "keyring.json")
+	t.Setenv(keyring.FileEnv, ringPath)
+	ring := &keyring.FileKeyring{Path: ringPath}
+	if err := ring.Set(config.KeyringService, "azure", "azure-secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SaveConfig(config.Config{Provider: "azure", APIKeyStore: config.KeyringStore}); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := config.LoadEffective()
+	if err != nil {
+		t.Fatalf("LoadEffective() error: %v", err)
+	}
+	if e.Config.APIKey != "azure-secret" || e.Origin("api_key") != "keyring" {
+		t.Errorf("API key = %q from %q, want the keyring's", e.Config.APIKey, e.Origin("api_key"))
+	}
+
+	// The environment still takes precedence.
+	t.Setenv("AZURE_OPENAI_API_KEY", "env-secret")
+	if cfg, _ := config.LoadConfig(); cfg.APIKey != "env-secret" {
+		t.Errorf("API key = %q, want the environment's", cfg.APIKey)
+	}
+}
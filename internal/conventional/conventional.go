@@ -0,0 +1,231 @@
+// Package conventional parses and validates commit messages written in the
+// Conventional Commits format (https://www.conventionalcommits.org).
+package conventional
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultTypes are the commit types accepted by DefaultRules, following the
+// Angular convention most tooling understands.
+var DefaultTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// Footer is a single git trailer-style footer such as "Refs: #123".
+type Footer struct {
+	Token string
+	Value string
+}
+
+// Commit is a parsed conventional commit message.
+type Commit struct {
+	Type        string
+	Scope       string
+	Description string
+	Body        string
+	Footers     []Footer
+	// Breaking is true when the header has a "!" or a BREAKING CHANGE footer is present.
+	Breaking bool
+	// BreakingNote is the text of the BREAKING CHANGE footer, or the
+	// description when the change is only marked with "!".
+	BreakingNote string
+}
+
+// headerPattern matches "type(scope)!: description".
+var headerPattern = regexp.MustCompile(`^(\w+)(?:\(([^()]*)\))?(!)?: (.*)$`)
+
+// footerPattern matches the start of a footer: "Token: value" or "Token #value".
+var footerPattern = regexp.MustCompile(`^(BREAKING CHANGE|BREAKING-CHANGE|[\w-]+)(: | #)(.*)$`)
+
+// Parse parses message as a conventional commit. Comment lines are not
+// stripped; callers should clean the message first.
+func Parse(message string) (*Commit, error) {
+	message = strings.TrimSpace(message)
+	header, rest, _ := strings.Cut(message, "\n")
+
+	m := headerPattern.FindStringSubmatch(header)
+	if m == nil {
+		return nil, fmt.Errorf("header %q is not in the form \"type(scope): description\"", header)
+	}
+	c := &Commit{
+		Type:        m[1],
+		Scope:       m[2],
+		Breaking:    m[3] == "!",
+		Description: strings.TrimSpace(m[4]),
+	}
+
+	body, footers := splitFooters(strings.Trim(rest, "\n"))
+	c.Body = body
+	c.Footers = footers
+	for _, f := range footers {
+		if f.Token == "BREAKING CHANGE" || f.Token == "BREAKING-CHANGE" {
+			c.Breaking = true
+			c.BreakingNote = f.Value
+		}
+	}
+	if c.Breaking && c.BreakingNote == "" {
+		c.BreakingNote = c.Description
+	}
+	return c, nil
+}
+
+// splitFooters separates the trailing footer paragraph, if any, from the body.
+func splitFooters(text string) (string, []Footer) {
+	if text == "" {
+		return "", nil
+	}
+	paragraphs := strings.Split(text, "\n\n")
+	last := paragraphs[len(paragraphs)-1]
+	lines := strings.Split(last, "\n")
+	if !footerPattern.MatchString(lines[0]) {
+		return text, nil
+	}
+
+	var footers []Footer
+	for _, line := range lines {
+		if m := footerPattern.FindStringSubmatch(line); m != nil {
+			value := m[3]
+			if m[2] == " #" {
+				value = "#" + value
+			}
+			footers = append(footers, Footer{Token: m[1], Value: value})
+			continue
+		}
+		// Continuation of a multi-line footer value.
+		footers[len(footers)-1].Value += "\n" + line
+	}
+	body := strings.Join(paragraphs[:len(paragraphs)-1], "\n\n")
+	return strings.TrimSpace(body), footers
+}
+
+// Rules configures Lint.
+type Rules struct {
+	// Types lists the allowed commit types.
+	Types []string
+	// MaxHeaderLength is the longest allowed first line.
+	MaxHeaderLength int
+	// MaxBodyLineLength is the longest allowed body line. Lines without
+	// spaces, such as URLs, are exempt.
+	MaxBodyLineLength int
+}
+
+// DefaultRules returns the rules used by gitter unless configured otherwise.
+func DefaultRules() Rules {
+	return Rules{
+		Types:             DefaultTypes,
+		MaxHeaderLength:   72,
+		MaxBodyLineLength: 100,
+	}
+}
+
+// Violation is a single rule a message breaks.
+type Violation struct {
+	// Line is the 1-based line number the violation refers to.
+	Line    int
+	Message string
+}
+
+// String formats the violation as "line N: message".
+func (v Violation) String() string {
+	return fmt.Sprintf("line %d: %s", v.Line, v.Message)
+}
+
+// Ignored reports whether message is one that conventional commit linting
+// should skip: merge commits, git's own revert messages and autosquash commits.
+func Ignored(message string) bool {
+	for _, prefix := range []string{"Merge ", "Revert \"", "fixup! ", "squash! ", "amend! "} {
+		if strings.HasPrefix(message, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint checks message against rules and returns every violation found.
+// Ignored messages never have violations.
+func Lint(message string, rules Rules) []Violation {
+	message = strings.TrimRight(message, "\n")
+	if Ignored(message) {
+		return nil
+	}
+	lines := strings.Split(message, "\n")
+	header := lines[0]
+
+	var violations []Violation
+	add := func(line int, format string, args ...interface{}) {
+		violations = append(violations, Violation{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(message) == "" {
+		add(1, "message is empty")
+		return violations
+	}
+
+	if len(header) > rules.MaxHeaderLength {
+		add(1, "header is %d characters long, the maximum is %d", len(header), rules.MaxHeaderLength)
+	}
+
+	m := headerPattern.FindStringSubmatch(header)
+	if m == nil {
+		add(1, "header must look like \"type(scope): description\", e.g. \"feat(cli): add lint command\"")
+	} else {
+		commitType, scope, description := m[1], m[2], m[4]
+		if !slices.Contains(rules.Types, commitType) {
+			add(1, "type %q is not one of %s", commitType, strings.Join(rules.Types, ", "))
+		}
+		if strings.HasPrefix(header[len(commitType):], "(") && strings.TrimSpace(scope) == "" {
+			add(1, "scope must not be empty when parentheses are used")
+		}
+		if strings.TrimSpace(description) == "" {
+			add(1, "description must not be empty")
+		} else if strings.HasSuffix(description, ".") {
+			add(1, "description must not end with a period")
+		}
+	}
+
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		add(2, "the header must be followed by a blank line")
+	}
+
+	for i, line := range lines[1:] {
+		lineNo := i + 2
+		if len(line) > rules.MaxBodyLineLength && strings.ContainsAny(strings.TrimSpace(line), " \t") {
+			add(lineNo, "line is %d characters long, wrap the body at %d", len(line), rules.MaxBodyLineLength)
+		}
+	}
+
+	// Footers are only ever in the trailing paragraph; elsewhere "breaking
+	// change" is just prose.
+	for i := footerStart(lines); i > 0 && i < len(lines); i++ {
+		line := lines[i]
+		if m := footerPattern.FindStringSubmatch(line); m != nil && (m[1] == "BREAKING CHANGE" || m[1] == "BREAKING-CHANGE") {
+			if m[2] != ": " {
+				add(i+1, "breaking changes must be written as \"BREAKING CHANGE: <description>\"")
+			} else if strings.TrimSpace(m[3]) == "" {
+				add(i+1, "BREAKING CHANGE footer must describe the change")
+			}
+		} else if looseBreakingPattern.MatchString(line) {
+			add(i+1, "breaking changes must be written as \"BREAKING CHANGE: <description>\"")
+		}
+	}
+
+	return violations
+}
+
+// looseBreakingPattern matches attempts at a BREAKING CHANGE footer in the
+// wrong case or punctuation, such as "Breaking change: ...".
+var looseBreakingPattern = regexp.MustCompile(`(?i)^breaking[ -]change\s*[:#]`)
+
+// footerStart returns the index of the first line of the trailing paragraph
+// of a message split into lines, or 0 if the message has only a header
+// paragraph.
+func footerStart(lines []string) int {
+	for i := len(lines) - 1; i > 0; i-- {
+		if strings.TrimSpace(lines[i]) == "" {
+			return i + 1
+		}
+	}
+	return 0
+}
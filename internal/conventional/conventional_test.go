@@ -0,0 +1,84 @@
+package conventional_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/biswajitpain/gitter/internal/conventional"
+)
+
+func TestParse(t *testing.T) {
+	message := "feat(api)!: drop v1 endpoints\n\n" +
+		"The v1 endpoints have been deprecated for a year.\n\n" +
+		"BREAKING CHANGE: clients must use /v2\n" +
+		"Refs #42\n"
+
+	c, err := conventional.Parse(message)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if c.Type != "feat" || c.Scope != "api" || c.Description != "drop v1 endpoints" {
+		t.Errorf("unexpected header fields: %+v", c)
+	}
+	if !c.Breaking || c.BreakingNote != "clients must use /v2" {
+		t.Errorf("Breaking = %v, BreakingNote = %q", c.Breaking, c.BreakingNote)
+	}
+	if c.Body != "The v1 endpoints have been deprecated for a year." {
+		t.Errorf("Body = %q", c.Body)
+	}
+	if len(c.Footers) != 2 || c.Footers[1].Token != "Refs" || c.Footers[1].Value != "#42" {
+		t.Errorf("Footers = %+v", c.Footers)
+	}
+
+	c, err = conventional.Parse("fix!: stop crashing")
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if !c.Breaking || c.BreakingNote != "stop crashing" {
+		t.Errorf("a '!' header should be breaking with the description as note, got %+v", c)
+	}
+
+	if _, err := conventional.Parse("Update stuff"); err == nil {
+		t.Error("Parse() of a non-conventional header should fail")
+	}
+}
+
+func TestLint(t *testing.T) {
+	rules := conventional.DefaultRules()
+
+	tests := []struct {
+		name    string
+		message string
+		want    []string // substrings of expected violations, in order
+	}{
+		{name: "valid", message: "feat(cli): add lint command\n\nExplain why.\n"},
+		{name: "valid with parens in description", message: "fix: handle (nil) configs"},
+		{name: "not conventional", message: "Added a thing", want: []string{"header must look like"}},
+		{name: "unknown type", message: "feature: add thing", want: []string{`type "feature"`}},
+		{name: "empty scope", message: "fix(): thing", want: []string{"scope must not be empty"}},
+		{name: "trailing period", message: "docs: update readme.", want: []string{"must not end with a period"}},
+		{name: "long header", message: "chore: " + strings.Repeat("x", 80), want: []string{"header is 87 characters"}},
+		{name: "missing blank line", message: "fix: thing\nbody", want: []string{"followed by a blank line"}},
+		{name: "long body line", message: "fix: thing\n\n" + strings.Repeat("word ", 30), want: []string{"wrap the body at 100"}},
+		{name: "long url is fine", message: "fix: thing\n\nhttps://example.com/" + strings.Repeat("x", 120)},
+		{name: "bad breaking footer", message: "feat: thing\n\nBreaking change: everything", want: []string{`"BREAKING CHANGE: <description>"`}},
+		{name: "empty breaking footer", message: "feat: thing\n\nBody.\n\nRefs: #1\nBREAKING CHANGE: ", want: []string{"must describe the change"}},
+		{name: "breaking prose in body", message: "perf: reuse cache entries\n\nBreaking changes to the cache are avoided by\nversioning its keys.\n\nRefs: #12"},
+		{name: "breaking prose in last paragraph", message: "perf: reuse cache entries\n\nBreaking changes to the cache are avoided."},
+		{name: "merge is ignored", message: "Merge branch 'main' into feature"},
+		{name: "fixup is ignored", message: "fixup! feat: thing"},
+	}
+
+	for _, tt := range tests {
+		violations := conventional.Lint(tt.message, rules)
+		if len(violations) != len(tt.want) {
+			t.Errorf("%s: got violations %v, want %d", tt.name, violations, len(tt.want))
+			continue
+		}
+		for i, want := range tt.want {
+			if !strings.Contains(violations[i].Message, want) {
+				t.Errorf("%s: violation %q does not mention %q", tt.name, violations[i].Message, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,426 @@
+// Package diff parses the unified diffs produced by git into files, hunks
+// and lines.
+//
+// It understands git's extended headers, so added, deleted, renamed and
+// copied files, mode changes and binary files are all reported, and it can
+// render a file or a subset of its hunks back into a patch that "git apply"
+// accepts.
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ChangeType describes what happened to a file.
+type ChangeType int
+
+const (
+	Modified ChangeType = iota
+	Added
+	Deleted
+	Renamed
+	Copied
+)
+
+// String returns the lower-case name of the change type.
+func (c ChangeType) String() string {
+	switch c {
+	case Added:
+		return "added"
+	case Deleted:
+		return "deleted"
+	case Renamed:
+		return "renamed"
+	case Copied:
+		return "copied"
+	default:
+		return "modified"
+	}
+}
+
+// LineKind describes a single line of a hunk.
+type LineKind int
+
+const (
+	Context LineKind = iota
+	Addition
+	Deletion
+	// NoNewline is the "\ No newline at end of file" marker that applies to
+	// the line before it.
+	NoNewline
+)
+
+// Line is a single line of a hunk without its leading marker character.
+type Line struct {
+	Kind    LineKind
+	Content string
+}
+
+// Hunk is a contiguous block of changes within a file.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	// Section is the text after the closing "@@", usually the enclosing function.
+	Section string
+	Lines   []Line
+}
+
+// Added returns the number of lines the hunk adds.
+func (h *Hunk) Added() int {
+	return h.count(Addition)
+}
+
+// Removed returns the number of lines the hunk removes.
+func (h *Hunk) Removed() int {
+	return h.count(Deletion)
+}
+
+func (h *Hunk) count(kind LineKind) int {
+	n := 0
+	for _, line := range h.Lines {
+		if line.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// String renders the hunk in unified diff format.
+func (h *Hunk) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+	if h.Section != "" {
+		b.WriteString(" " + h.Section)
+	}
+	b.WriteString("\n")
+	for _, line := range h.Lines {
+		switch line.Kind {
+		case Addition:
+			b.WriteString("+")
+		case Deletion:
+			b.WriteString("-")
+		case NoNewline:
+			b.WriteString("\\")
+		default:
+			b.WriteString(" ")
+		}
+		b.WriteString(line.Content + "\n")
+	}
+	return b.String()
+}
+
+// hunkRange formats one side of a hunk header, omitting a count of one as git does.
+func hunkRange(start, lines int) string {
+	if lines == 1 {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// File is the diff of a single file.
+type File struct {
+	// OldPath and NewPath are the paths before and after the change without
+	// their a/ and b/ prefixes. For added files OldPath is empty and for
+	// deleted files NewPath is empty.
+	OldPath, NewPath string
+	Change           ChangeType
+	// OldMode and NewMode are set when the file mode is known, e.g. "100644".
+	OldMode, NewMode string
+	// Similarity is the similarity index of a rename or copy, in percent.
+	Similarity int
+	// Binary is true when git reported the file as binary.
+	Binary bool
+	// Header holds the raw lines preceding the hunks, starting with "diff --git".
+	// For binary patches it also holds the patch data.
+	Header []string
+	Hunks  []*Hunk
+}
+
+// Path returns the path the change is best known by: the new path, or the
+// old path for deleted files.
+func (f *File) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// ModeChanged reports whether the file's mode differs before and after.
+func (f *File) ModeChanged() bool {
+	return f.OldMode != "" && f.NewMode != "" && f.OldMode != f.NewMode
+}
+
+// Added returns the number of lines added to the file.
+func (f *File) Added() int {
+	n := 0
+	for _, h := range f.Hunks {
+		n += h.Added()
+	}
+	return n
+}
+
+// Removed returns the number of lines removed from the file.
+func (f *File) Removed() int {
+	n := 0
+	for _, h := range f.Hunks {
+		n += h.Removed()
+	}
+	return n
+}
+
+// String renders the file's diff, header and all hunks, in unified diff format.
+func (f *File) String() string {
+	return f.Patch(f.Hunks)
+}
+
+// Patch renders the file's header followed by the given hunks, which should
+// be a subset of f.Hunks in order. The result can be applied with "git apply".
+func (f *File) Patch(hunks []*Hunk) string {
+	var b strings.Builder
+	for _, line := range f.Header {
+		b.WriteString(line + "\n")
+	}
+	for _, h := range hunks {
+		b.WriteString(h.String())
+	}
+	return b.String()
+}
+
+// Parse parses the output of "git diff" (or "git show", "git log -p" after
+// the commit header) into its files. Text before the first "diff --git" line
+// is ignored.
+func Parse(text string) ([]*File, error) {
+	var files []*File
+	var file *File
+	var hunk *Hunk
+	var oldLeft, newLeft int
+
+	lines := strings.Split(text, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	for i, line := range lines {
+		// Inside a hunk, the header counts tell us exactly how many lines
+		// belong to it, so content such as "--- x" is never mistaken for a header.
+		if hunk != nil && (oldLeft > 0 || newLeft > 0 || strings.HasPrefix(line, "\\")) {
+			kind, content, err := parseHunkLine(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			switch kind {
+			case Context:
+				oldLeft--
+				newLeft--
+			case Deletion:
+				oldLeft--
+			case Addition:
+				newLeft--
+			}
+			if oldLeft < 0 || newLeft < 0 {
+				return nil, fmt.Errorf("line %d: hunk is longer than its header says", i+1)
+			}
+			hunk.Lines = append(hunk.Lines, Line{Kind: kind, Content: content})
+			continue
+		}
+		hunk = nil
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file = &File{Header: []string{line}}
+			file.OldPath, file.NewPath = parseGitPaths(strings.TrimPrefix(line, "diff --git "))
+			files = append(files, file)
+		case file == nil:
+			// Preamble such as a commit message; not part of any file.
+		case strings.HasPrefix(line, "@@ "):
+			h, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			hunk = h
+			oldLeft, newLeft = h.OldLines, h.NewLines
+			file.Hunks = append(file.Hunks, h)
+		default:
+			file.Header = append(file.Header, line)
+			parseHeaderLine(file, line)
+		}
+	}
+
+	return files, nil
+}
+
+// parseHunkLine splits a hunk body line into its kind and content.
+func parseHunkLine(line string) (LineKind, string, error) {
+	if line == "" {
+		// Some tools strip the trailing space of empty context lines.
+		return Context, "", nil
+	}
+	switch line[0] {
+	case ' ':
+		return Context, line[1:], nil
+	case '+':
+		return Addition, line[1:], nil
+	case '-':
+		return Deletion, line[1:], nil
+	case '\\':
+		return NoNewline, line[1:], nil
+	}
+	return Context, "", fmt.Errorf("unexpected line in hunk: %q", line)
+}
+
+// parseHunkHeader parses a line such as "@@ -1,5 +1,6 @@ func main() {".
+func parseHunkHeader(line string) (*Hunk, error) {
+	rest := strings.TrimPrefix(line, "@@ ")
+	end := strings.Index(rest, " @@")
+	if end < 0 {
+		return nil, fmt.Errorf("malformed hunk header: %q", line)
+	}
+	ranges := strings.Fields(rest[:end])
+	if len(ranges) != 2 || !strings.HasPrefix(ranges[0], "-") || !strings.HasPrefix(ranges[1], "+") {
+		return nil, fmt.Errorf("malformed hunk header: %q", line)
+	}
+
+	h := &Hunk{Section: strings.TrimSpace(rest[end+3:])}
+	var err error
+	if h.OldStart, h.OldLines, err = parseRange(ranges[0][1:]); err != nil {
+		return nil, fmt.Errorf("malformed hunk header %q: %w", line, err)
+	}
+	if h.NewStart, h.NewLines, err = parseRange(ranges[1][1:]); err != nil {
+		return nil, fmt.Errorf("malformed hunk header %q: %w", line, err)
+	}
+	return h, nil
+}
+
+// parseRange parses "start,count" or "start" (count of one).
+func parseRange(s string) (int, int, error) {
+	startStr, countStr, hasCount := strings.Cut(s, ",")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !hasCount {
+		return start, 1, nil
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, count, nil
+}
+
+// parseHeaderLine updates file from one of git's extended header lines.
+func parseHeaderLine(file *File, line string) {
+	switch {
+	case strings.HasPrefix(line, "new file mode "):
+		file.Change = Added
+		file.NewMode = strings.TrimPrefix(line, "new file mode ")
+		file.OldPath = ""
+	case strings.HasPrefix(line, "deleted file mode "):
+		file.Change = Deleted
+		file.OldMode = strings.TrimPrefix(line, "deleted file mode ")
+		file.NewPath = ""
+	case strings.HasPrefix(line, "old mode "):
+		file.OldMode = strings.TrimPrefix(line, "old mode ")
+	case strings.HasPrefix(line, "new mode "):
+		file.NewMode = strings.TrimPrefix(line, "new mode ")
+	case strings.HasPrefix(line, "index "):
+		// "index abc123..def456 100644" carries the mode of unchanged-mode files.
+		if fields := strings.Fields(line); len(fields) == 3 && file.OldMode == "" && file.NewMode == "" {
+			file.OldMode, file.NewMode = fields[2], fields[2]
+		}
+	case strings.HasPrefix(line, "similarity index "):
+		file.Similarity, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"))
+	case strings.HasPrefix(line, "rename from "):
+		file.Change = Renamed
+		file.OldPath = unquote(strings.TrimPrefix(line, "rename from "))
+	case strings.HasPrefix(line, "rename to "):
+		file.Change = Renamed
+		file.NewPath = unquote(strings.TrimPrefix(line, "rename to "))
+	case strings.HasPrefix(line, "copy from "):
+		file.Change = Copied
+		file.OldPath = unquote(strings.TrimPrefix(line, "copy from "))
+	case strings.HasPrefix(line, "copy to "):
+		file.Change = Copied
+		file.NewPath = unquote(strings.TrimPrefix(line, "copy to "))
+	case strings.HasPrefix(line, "--- "):
+		if p := headerPath(strings.TrimPrefix(line, "--- "), "a/"); p != "" || file.Change == Added {
+			file.OldPath = p
+		}
+	case strings.HasPrefix(line, "+++ "):
+		if p := headerPath(strings.TrimPrefix(line, "+++ "), "b/"); p != "" || file.Change == Deleted {
+			file.NewPath = p
+		}
+	case strings.HasPrefix(line, "Binary files "), line == "GIT binary patch":
+		file.Binary = true
+	}
+}
+
+// headerPath extracts the path from a "---" or "+++" line, returning "" for /dev/null.
+func headerPath(s string, prefix string) string {
+	// Non-git diffs may append a tab and a timestamp.
+	if i := strings.IndexByte(s, '\t'); i >= 0 && !strings.HasPrefix(s, `"`) {
+		s = s[:i]
+	}
+	s = unquote(s)
+	if s == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(s, prefix)
+}
+
+// parseGitPaths extracts both paths from the rest of a "diff --git" line.
+// Paths containing spaces are ambiguous here; later header lines correct them.
+func parseGitPaths(s string) (string, string) {
+	if strings.HasPrefix(s, `"`) {
+		// Quoted old path, e.g. "a/with space" b/with space
+		if end := closingQuote(s); end > 0 {
+			oldPath := unquote(s[:end+1])
+			newPath := unquote(strings.TrimSpace(s[end+1:]))
+			return strings.TrimPrefix(oldPath, "a/"), strings.TrimPrefix(newPath, "b/")
+		}
+	}
+	if strings.HasSuffix(s, `"`) {
+		if i := strings.LastIndex(s, ` "b/`); i >= 0 {
+			return strings.TrimPrefix(s[:i], "a/"), strings.TrimPrefix(unquote(s[i+1:]), "b/")
+		}
+	}
+	// For unquoted paths, both halves are the same length unless the file was renamed.
+	if len(s)%2 == 1 {
+		half := len(s) / 2
+		oldPath, newPath := s[:half], s[half+1:]
+		if strings.TrimPrefix(oldPath, "a/") == strings.TrimPrefix(newPath, "b/") {
+			return strings.TrimPrefix(oldPath, "a/"), strings.TrimPrefix(newPath, "b/")
+		}
+	}
+	if i := strings.LastIndex(s, " b/"); i >= 0 {
+		return strings.TrimPrefix(s[:i], "a/"), s[i+3:]
+	}
+	return s, s
+}
+
+// closingQuote returns the index of the quote closing the string that starts at s[0].
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// unquote decodes a path that git quoted because it contains special
+// characters. Unquoted paths are returned unchanged.
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	return s
+}
@@ -0,0 +1,164 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/biswajitpain/gitter/internal/diff"
+)
+
+// sampleDiff is real "git diff --staged -M" output covering a deletion, a
+// binary addition, a modification, a rename and a mode change.
+const sampleDiff = "diff --git a/del.txt b/del.txt\n" +
+	"deleted file mode 100644\n" +
+	"index 286c5f5..0000000\n" +
+	"--- a/del.txt\n" +
+	"+++ /dev/null\n" +
+	"@@ -1 +0,0 @@\n" +
+	"-gone\n" +
+	"diff --git a/img.bin b/img.bin\n" +
+	"new file mode 100644\n" +
+	"index 0000000..8352675\n" +
+	"Binary files /dev/null and b/img.bin differ\n" +
+	"diff --git a/keep.txt b/keep.txt\n" +
+	"index 2019eda..c0a10db 100644\n" +
+	"--- a/keep.txt\n" +
+	"+++ b/keep.txt\n" +
+	"@@ -1,7 +1,9 @@\n" +
+	" one\n" +
+	"-two\n" +
+	"+2\n" +
+	" three\n" +
+	" four\n" +
+	" five\n" +
+	" six\n" +
+	" seven\n" +
+	"+-- x\n" +
+	"+no newline\n" +
+	"\\ No newline at end of file\n" +
+	"diff --git a/old name.txt b/new name.txt\n" +
+	"similarity index 85%\n" +
+	"rename from old name.txt\n" +
+	"rename to new name.txt\n" +
+	"index 5fcb7b1..a3d0339 100644\n" +
+	"--- a/old name.txt\t\n" +
+	"+++ b/new name.txt\t\n" +
+	"@@ -4,3 +4,4 @@ line3\n" +
+	" line4\n" +
+	" line5\n" +
+	" line6\n" +
+	"+line7\n" +
+	"diff --git a/run.sh b/run.sh\n" +
+	"old mode 100644\n" +
+	"new mode 100755\n"
+
+func TestParse(t *testing.T) {
+	files, err := diff.Parse(sampleDiff)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if len(files) != 5 {
+		t.Fatalf("Parse() returned %d files, want 5", len(files))
+	}
+
+	tests := []struct {
+		oldPath, newPath string
+		change           diff.ChangeType
+		added, removed   int
+		binary           bool
+		modeChanged      bool
+	}{
+		{oldPath: "del.txt", newPath: "", change: diff.Deleted, removed: 1},
+		{oldPath: "", newPath: "img.bin", change: diff.Added, binary: true},
+		{oldPath: "keep.txt", newPath: "keep.txt", change: diff.Modified, added: 3, removed: 1},
+		{oldPath: "old name.txt", newPath: "new name.txt", change: diff.Renamed, added: 1},
+		{oldPath: "run.sh", newPath: "run.sh", change: diff.Modified, modeChanged: true},
+	}
+	for i, tt := range tests {
+		f := files[i]
+		if f.OldPath != tt.oldPath || f.NewPath != tt.newPath {
+			t.Errorf("file %d: paths = (%q, %q), want (%q, %q)", i, f.OldPath, f.NewPath, tt.oldPath, tt.newPath)
+		}
+		if f.Change != tt.change {
+			t.Errorf("file %d: change = %s, want %s", i, f.Change, tt.change)
+		}
+		if f.Added() != tt.added || f.Removed() != tt.removed {
+			t.Errorf("file %d: +%d -%d, want +%d -%d", i, f.Added(), f.Removed(), tt.added, tt.removed)
+		}
+		if f.Binary != tt.binary {
+			t.Errorf("file %d: binary = %v, want %v", i, f.Binary, tt.binary)
+		}
+		if f.ModeChanged() != tt.modeChanged {
+			t.Errorf("file %d: mode changed = %v, want %v", i, f.ModeChanged(), tt.modeChanged)
+		}
+	}
+
+	if files[0].Path() != "del.txt" {
+		t.Errorf("Path() of a deleted file = %q, want the old path", files[0].Path())
+	}
+	if files[3].Similarity != 85 {
+		t.Errorf("rename similarity = %d, want 85", files[3].Similarity)
+	}
+	if h := files[3].Hunks[0]; h.OldStart != 4 || h.OldLines != 3 || h.NewStart != 4 || h.NewLines != 4 || h.Section != "line3" {
+		t.Errorf("unexpected hunk header: %+v", h)
+	}
+	last := files[2].Hunks[0].Lines[len(files[2].Hunks[0].Lines)-1]
+	if last.Kind != diff.NoNewline {
+		t.Errorf("last line of keep.txt hunk should be the no-newline marker, got %+v", last)
+	}
+}
+
+func TestParse_RoundTrip(t *testing.T) {
+	files, err := diff.Parse(sampleDiff)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	var b strings.Builder
+	for _, f := range files {
+		b.WriteString(f.String())
+	}
+	if b.String() != sampleDiff {
+		t.Errorf("rendering the parsed diff did not reproduce the input:\n%s", b.String())
+	}
+}
+
+func TestParse_HeaderLikeContent(t *testing.T) {
+	// A removed line "-- x" renders as "--- x" and must not end the hunk.
+	text := "diff --git a/sql.txt b/sql.txt\n" +
+		"--- a/sql.txt\n" +
+		"+++ b/sql.txt\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		"--- comment\n" +
+		"+++ more\n" +
+		" select 1;\n"
+	files, err := diff.Parse(text)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Added() != 1 || files[0].Removed() != 1 {
+		t.Fatalf("unexpected result: %+v", files)
+	}
+	if files[0].Hunks[0].Lines[0].Content != "-- comment" {
+		t.Errorf("first line content = %q, want %q", files[0].Hunks[0].Lines[0].Content, "-- comment")
+	}
+}
+
+func TestParse_QuotedPaths(t *testing.T) {
+	text := "diff --git \"a/caf\\303\\251.txt\" \"b/caf\\303\\251.txt\"\n" +
+		"new file mode 100644\n" +
+		"index 0000000..e69de29\n"
+	files, err := diff.Parse(text)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Path() != "café.txt" || files[0].Change != diff.Added {
+		t.Errorf("unexpected result: %+v", files[0])
+	}
+}
+
+func TestParse_Malformed(t *testing.T) {
+	text := "diff --git a/x b/x\n--- a/x\n+++ b/x\n@@ -1,2 +1 @@\n-a\n*b\n"
+	if _, err := diff.Parse(text); err == nil {
+		t.Error("Parse() of a malformed hunk should return an error")
+	}
+}
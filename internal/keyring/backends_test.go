@@ -0,0 +1,40 @@
+package keyring
+
+import (
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestSet_SecretNotInArguments checks that the system keyrings are given
+// the secret on stdin, never as an argument that ps would show.
+func TestSet_SecretNotInArguments(t *testing.T) {
+	const secret = "sk-very-secret"
+	defer func() { execCommand = exec.Command }()
+	for _, ring := range []Keyring{secretTool{}, keychain{}} {
+		stdin := filepath.Join(t.TempDir(), "stdin")
+		var args []string
+		execCommand = func(name string, arg ...string) *exec.Cmd {
+			args = append([]string{name}, arg...)
+			return exec.Command("sh", "-c", `cat > "$0"`, stdin)
+		}
+
+		if err := ring.Set("gitter", "openai", secret); err != nil {
+			t.Fatalf("%T.Set() error: %v", ring, err)
+		}
+		if slices.ContainsFunc(args, func(arg string) bool { return strings.Contains(arg, secret) }) {
+			t.Errorf("%T.Set() passed the secret as an argument: %q", ring, args)
+		}
+		input, err := os.ReadFile(stdin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(input), secret) && !strings.Contains(string(input), hex.EncodeToString([]byte(secret))) {
+			t.Errorf("%T.Set() did not give the secret on stdin: %q", ring, input)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Package keyring stores secrets such as API keys in the operating
+// system's keyring instead of a plaintext file: the Secret Service on
+// Linux, through secret-tool, and the login keychain on macOS, through
+// security.
+package keyring
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// FileEnv names an environment variable that, when set, makes Default
+// return a FileKeyring at that path. It is meant for tests and for
+// machines without a keyring daemon.
+const FileEnv = "GITTER_KEYRING_FILE"
+
+// execCommand runs the keyring commands. It is a variable so that tests can
+// see what the commands are given.
+var execCommand = exec.Command
+
+// ErrNotFound is returned by Get when no secret is stored.
+var ErrNotFound = errors.New("secret not found in keyring")
+
+// ErrUnavailable is returned by Default when the system has no usable keyring.
+var ErrUnavailable = errors.New("no keyring available")
+
+// Keyring stores secrets by service and account.
+type Keyring interface {
+	// Get returns the secret, or ErrNotFound.
+	Get(service, account string) (string, error)
+	// Set stores the secret, replacing any stored before.
+	Set(service, account, secret string) error
+	// Delete removes the secret. Deleting a missing secret is not an error.
+	Delete(service, account string) error
+}
+
+// Default returns the keyring of this system, or an error wrapping
+// ErrUnavailable.
+func Default() (Keyring, error) {
+	if path := os.Getenv(FileEnv); path != "" {
+		return &FileKeyring{Path: path}, nil
+	}
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		// secret-tool talks to the Secret Service over the session bus,
+		// which headless machines and containers usually do not have.
+		if _, err := exec.LookPath("secret-tool"); err != nil {
+			return nil, fmt.Errorf("%w: secret-tool is not installed", ErrUnavailable)
+		}
+		if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+			return nil, fmt.Errorf("%w: no D-Bus session", ErrUnavailable)
+		}
+		return secretTool{}, nil
+	case "darwin":
+		if _, err := exec.LookPath("security"); err != nil {
+			return nil, fmt.Errorf("%w: security is not installed", ErrUnavailable)
+		}
+		return keychain{}, nil
+	default:
+		return nil, fmt.Errorf("%w on %s", ErrUnavailable, runtime.GOOS)
+	}
+}
+
+// secretTool uses the Secret Service through the secret-tool command.
+type secretTool struct{}
+
+func (secretTool) Get(service, account string) (string, error) {
+	out, err := execCommand("secret-tool", "lookup", "service", service, "account", account).Output()
+	if err != nil {
+		// secret-tool exits with 1 and prints nothing if there is no secret.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) == 0 {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("could not read from the keyring: %w", commandError(err))
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (secretTool) Set(service, account, secret string) error {
+	cmd := execCommand("secret-tool", "store", "--label", "gitter: "+account, "service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	if _, err := cmd.Output(); err != nil {
+		return fmt.Errorf("could not write to the keyring: %w", commandError(err))
+	}
+	return nil
+}
+
+func (secretTool) Delete(service, account string) error {
+	if _, err := execCommand("secret-tool", "clear", "service", service, "account", account).Output(); err != nil {
+		return fmt.Errorf("could not delete from the keyring: %w", commandError(err))
+	}
+	return nil
+}
+
+// keychain uses the macOS login keychain through the security command.
+type keychain struct{}
+
+// keychainNotFound is the exit code of security when no item matches.
+const keychainNotFound = 44
+
+func (keychain) Get(service, account string) (string, error) {
+	out, err := execCommand("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == keychainNotFound {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("could not read from the keychain: %w", commandError(err))
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (keychain) Set(service, account, secret string) error {
+	// The secret must not be an argument, where any user could see it with
+	// ps, so the command is given to security's interactive mode on stdin,
+	// with the secret hex-encoded to need no quoting.
+	cmd := execCommand("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+		keychainQuote(service), keychainQuote(account), hex.EncodeToString([]byte(secret))))
+	// In interactive mode security exits with 0 even if the command fails,
+	// so what it prints on stderr is the only sign of failure.
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not write to the keychain: %w", err)
+	}
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return fmt.Errorf("could not write to the keychain: %s", msg)
+	}
+	return nil
+}
+
+func (keychain) Delete(service, account string) error {
+	_, err := execCommand("security", "delete-generic-password", "-s", service, "-a", account).Output()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == keychainNotFound) {
+		return fmt.Errorf("could not delete from the keychain: %w", commandError(err))
+	}
+	return nil
+}
+
+// keychainQuote quotes s as one word of a security -i command line.
+func keychainQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// commandError adds what a failed command printed on stderr to err.
+func commandError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
+
+// FileKeyring keeps secrets in a JSON file readable only by the user. It
+// is no safer than the config file and exists for tests and machines
+// without a keyring.
+type FileKeyring struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// load reads the stored secrets, keyed by "service/account".
+func (k *FileKeyring) load() (map[string]string, error) {
+	secrets := map[string]string{}
+	data, err := os.ReadFile(k.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read keyring file: %w", err)
+	}
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, fmt.Errorf("could not decode keyring file: %w", err)
+	}
+	return secrets, nil
+}
+
+// save writes secrets back to the file.
+func (k *FileKeyring) save(secrets map[string]string) error {
+	data, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode keyring file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(k.Path), 0700); err != nil {
+		return fmt.Errorf("could not create keyring directory: %w", err)
+	}
+	if err := os.WriteFile(k.Path, data, 0600); err != nil {
+		return fmt.Errorf("could not write keyring file: %w", err)
+	}
+	return nil
+}
+
+// Get returns the secret, or ErrNotFound.
+func (k *FileKeyring) Get(service, account string) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	secrets, err := k.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[service+"/"+account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set stores the secret.
+func (k *FileKeyring) Set(service, account, secret string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	secrets, err := k.load()
+	if err != nil {
+		return err
+	}
+	secrets[service+"/"+account] = secret
+	return k.save(secrets)
+}
+
+// Delete removes the secret.
+func (k *FileKeyring) Delete(service, account string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	secrets, err := k.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[service+"/"+account]; !ok {
+		return nil
+	}
+	delete(secrets, service+"/"+account)
+	return k.save(secrets)
+}
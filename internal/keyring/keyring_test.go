@@ -0,0 +1,62 @@
+package keyring_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/biswajitpain/gitter/internal/keyring"
+)
+
+func TestFileKeyring(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "keyring.json")
+	ring := &keyring.FileKeyring{Path: path}
+
+	if _, err := ring.Get("gitter", "openai"); !errors.Is(err, keyring.ErrNotFound) {
+		t.Fatalf("Get() on an empty keyring error = %v, want ErrNotFound", err)
+	}
+	if err := ring.Set("gitter", "openai", "sk-one"); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if err := ring.Set("gitter", "anthropic", "sk-two"); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if got, err := ring.Get("gitter", "openai"); err != nil || got != "sk-one" {
+		t.Errorf("Get() = %q, %v, want sk-one", got, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("keyring file permissions = %o, want 600", perm)
+	}
+
+	if err := ring.Delete("gitter", "openai"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if err := ring.Delete("gitter", "openai"); err != nil {
+		t.Errorf("Delete() of a missing secret error: %v", err)
+	}
+	if _, err := ring.Get("gitter", "openai"); !errors.Is(err, keyring.ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want ErrNotFound", err)
+	}
+	if got, _ := ring.Get("gitter", "anthropic"); got != "sk-two" {
+		t.Errorf("Delete() removed other secrets, Get() = %q", got)
+	}
+}
+
+func TestDefault_FileEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	t.Setenv(keyring.FileEnv, path)
+
+	ring, err := keyring.Default()
+	if err != nil {
+		t.Fatalf("Default() error: %v", err)
+	}
+	if f, ok := ring.(*keyring.FileKeyring); !ok || f.Path != path {
+		t.Errorf("Default() = %#v, want a FileKeyring at %s", ring, path)
+	}
+}
@@ -0,0 +1,210 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// anthropicVersion is the Messages API version sent with every request.
+const anthropicVersion = "2023-06-01"
+
+// defaultAnthropicModel is used when no model is configured.
+const defaultAnthropicModel = "claude-3-5-haiku-latest"
+
+// AnthropicClient is a client for the Anthropic Messages API.
+type AnthropicClient struct {
+	APIKey  string
+	BaseURL string
+	// Model is the Claude model to use. Defaults to defaultAnthropicModel when empty.
+	Model string
+	// Headers are extra HTTP headers sent with every request, e.g. for gateways.
+	Headers map[string]string
+}
+
+// anthropicRequest represents the request body for the Anthropic Messages API.
+type anthropicRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	System    string    `json:"system,omitempty"`
+	Messages  []message `json:"messages"`
+	Stream    bool      `json:"stream,omitempty"`
+}
+
+// anthropicResponse is the response from the Anthropic Messages API.
+// Failed requests use the same body with Type set to "error".
+type anthropicResponse struct {
+	Type    string `json:"type"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage anthropicUsage `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// anthropicUsage is the token usage of an Anthropic request.
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// anthropicStreamEvent is a single server-sent event of a streamed Anthropic response.
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	// Message is set on message_start and carries the input token count;
+	// Usage is set on message_delta and carries the output token count.
+	Message struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	Usage anthropicUsage `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// GenerateCommitMessage generates a commit message using the Anthropic API.
+func (c *AnthropicClient) GenerateCommitMessage(diff string, userMessage string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return c.Complete(ctx, systemPrompt, commitPrompt(diff, userMessage))
+}
+
+// StreamCommitMessage generates a commit message using the Anthropic API,
+// passing each text delta to onToken as it arrives over server-sent events.
+func (c *AnthropicClient) StreamCommitMessage(ctx context.Context, diff string, userMessage string, onToken func(string)) (string, error) {
+	resp, err := c.send(ctx, systemPrompt, commitPrompt(diff, userMessage), true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	err = readSSE(resp.Body, func(event string, data string) (bool, error) {
+		var chunk anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, fmt.Errorf("could not decode Anthropic stream: %w", err)
+		}
+		switch chunk.Type {
+		case "message_start":
+			recordUsage(ctx, chunk.Message.Usage.InputTokens, chunk.Message.Usage.OutputTokens)
+		case "message_delta":
+			recordUsage(ctx, chunk.Usage.InputTokens, chunk.Usage.OutputTokens)
+		case "content_block_delta":
+			if chunk.Delta.Type == "text_delta" && chunk.Delta.Text != "" {
+				text.WriteString(chunk.Delta.Text)
+				onToken(chunk.Delta.Text)
+			}
+		case "error":
+			if chunk.Error != nil {
+				return false, fmt.Errorf("Anthropic API error (%s): %s", chunk.Error.Type, chunk.Error.Message)
+			}
+			return false, fmt.Errorf("Anthropic API stream failed")
+		case "message_stop":
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no commit message generated by Anthropic")
+	}
+	return text.String(), nil
+}
+
+// Complete sends a single-turn request to the Anthropic Messages API.
+func (c *AnthropicClient) Complete(ctx context.Context, system string, prompt string) (string, error) {
+	resp, err := c.send(ctx, system, prompt, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var apiResp anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return "", fmt.Errorf("could not decode Anthropic response: %w", err)
+	}
+	recordUsage(ctx, apiResp.Usage.InputTokens, apiResp.Usage.OutputTokens)
+
+	var text strings.Builder
+	for _, block := range apiResp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no response generated by Anthropic")
+	}
+
+	return text.String(), nil
+}
+
+// send posts a Messages API request and returns the response once the
+// server has accepted it. The error envelope is decoded and returned as an error.
+func (c *AnthropicClient) send(ctx context.Context, system string, prompt string, stream bool) (*http.Response, error) {
+	if c.APIKey == "" {
+		return nil, fmt.Errorf("Anthropic API key is not set")
+	}
+
+	model := c.Model
+	if model == "" {
+		model = defaultAnthropicModel
+	}
+
+	reqBody := anthropicRequest{
+		Model:     model,
+		MaxTokens: 1024,
+		System:    system,
+		Messages: []message{
+			{Role: "user", Content: prompt},
+		},
+		Stream: stream,
+	}
+
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal Anthropic request: %w", err)
+	}
+
+	requestURL := fmt.Sprintf("%s/messages", c.BaseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", requestURL, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, fmt.Errorf("could not create Anthropic request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", c.APIKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+	setHeaders(req, c.Headers)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not send request to Anthropic: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var apiResp anthropicResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err == nil && apiResp.Error != nil {
+			return nil, fmt.Errorf("Anthropic API error (%s, %s): %s", resp.Status, apiResp.Error.Type, apiResp.Error.Message)
+		}
+		return nil, fmt.Errorf("Anthropic API request failed with status: %s", resp.Status)
+	}
+
+	return resp, nil
+}
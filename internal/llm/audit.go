@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"context"
+	"time"
+
+	"github.com/biswajitpain/gitter/internal/audit"
+)
+
+// AuditingClient wraps an LLMClient and records every request, its reply,
+// latency and token usage in the audit log. It should sit inside a
+// RedactingClient, so that what it records is what was actually sent.
+type AuditingClient struct {
+	Client LLMClient
+	// Path is the audit log to append to.
+	Path     string
+	Repo     string
+	Provider string
+	Model    string
+	// FullPrompts records the prompts themselves rather than only their hash.
+	FullPrompts bool
+	// OnError, if set, is called when an entry cannot be written. The
+	// request itself is not affected.
+	OnError func(err error)
+}
+
+// record appends an entry for a request that started at start.
+func (c *AuditingClient) record(kind, system, prompt string, start time.Time, usage Usage, reply string, err error) {
+	entry := audit.Entry{
+		ID:           audit.NewID(),
+		Time:         start,
+		Repo:         c.Repo,
+		Provider:     c.Provider,
+		Model:        c.Model,
+		Kind:         kind,
+		PromptSHA256: audit.HashPrompt(system, prompt),
+		PromptBytes:  len(system) + len(prompt),
+		Response:     reply,
+		LatencyMS:    time.Since(start).Milliseconds(),
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+	}
+	if c.FullPrompts {
+		entry.System, entry.Prompt = system, prompt
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if err := audit.Append(c.Path, entry); err != nil && c.OnError != nil {
+		c.OnError(err)
+	}
+}
+
+// GenerateCommitMessage calls the wrapped client and records the request.
+// The provider's token usage is not available on this path.
+func (c *AuditingClient) GenerateCommitMessage(diff string, userMessage string) (string, error) {
+	start := time.Now()
+	reply, err := c.Client.GenerateCommitMessage(diff, userMessage)
+	c.record("commit message", systemPrompt, commitPrompt(diff, userMessage), start, Usage{}, reply, err)
+	return reply, err
+}
+
+// StreamCommitMessage calls the wrapped client and records the request.
+func (c *AuditingClient) StreamCommitMessage(ctx context.Context, diff string, userMessage string, onToken func(string)) (string, error) {
+	var usage Usage
+	start := time.Now()
+	reply, err := c.Client.StreamCommitMessage(WithUsage(ctx, &usage), diff, userMessage, onToken)
+	c.record("commit message", systemPrompt, commitPrompt(diff, userMessage), start, usage, reply, err)
+	return reply, err
+}
+
+// Complete calls the wrapped client and records the request.
+func (c *AuditingClient) Complete(ctx context.Context, system string, prompt string) (string, error) {
+	var usage Usage
+	start := time.Now()
+	reply, err := c.Client.Complete(WithUsage(ctx, &usage), system, prompt)
+	c.record("completion", system, prompt, start, usage, reply, err)
+	return reply, err
+}
@@ -7,12 +7,21 @@ import (
 	"fmt"
 	"github.com/biswajitpain/gitter/internal/config"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // LLMClient is the interface for a client that can generate commit messages.
 type LLMClient interface {
 	GenerateCommitMessage(diff string, userMessage string) (string, error)
+	// StreamCommitMessage is like GenerateCommitMessage but calls onToken with
+	// each piece of the message as it is produced. Cancelling ctx aborts the
+	// request. The complete message is returned at the end.
+	StreamCommitMessage(ctx context.Context, diff string, userMessage string, onToken func(string)) (string, error)
+	// Complete sends a single system and user prompt and returns the model's reply.
+	Complete(ctx context.Context, system string, prompt string) (string, error)
 }
 
 // NewLLMClient returns an LLM client based on the provided config.
@@ -20,8 +29,62 @@ func NewLLMClient(cfg config.Config) (LLMClient, error) {
 	switch cfg.Provider {
 	case "openai":
 		return &OpenAIClient{
+			APIKey:     cfg.APIKey,
+			BaseURL:    orDefault(cfg.BaseURL, defaultOpenAIURL),
+			Model:      cfg.Model,
+			Headers:    cfg.Headers,
+			APIVersion: cfg.APIVersion,
+		}, nil
+	case "openai-compatible":
+		// vLLM, LiteLLM and similar gateways; the endpoint must be configured
+		// and many of them run without authentication.
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("provider %s requires a base URL", cfg.Provider)
+		}
+		return &OpenAIClient{
+			APIKey:      cfg.APIKey,
+			BaseURL:     cfg.BaseURL,
+			Model:       cfg.Model,
+			Headers:     cfg.Headers,
+			APIVersion:  cfg.APIVersion,
+			KeyOptional: true,
+		}, nil
+	case "azure":
+		// The base URL is the deployment URL, e.g.
+		// https://<resource>.openai.azure.com/openai/deployments/<deployment>
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("provider %s requires a base URL", cfg.Provider)
+		}
+		return &OpenAIClient{
+			APIKey:     cfg.APIKey,
+			BaseURL:    cfg.BaseURL,
+			Model:      cfg.Model,
+			Headers:    cfg.Headers,
+			APIVersion: orDefault(cfg.APIVersion, defaultAzureAPIVersion),
+			Azure:      true,
+		}, nil
+	case "anthropic":
+		return &AnthropicClient{
 			APIKey:  cfg.APIKey,
-			BaseURL: "https://api.openai.com/v1",
+			BaseURL: orDefault(cfg.BaseURL, "https://api.anthropic.com/v1"),
+			Model:   cfg.Model,
+			Headers: cfg.Headers,
+		}, nil
+	case "ollama":
+		return &OllamaClient{
+			BaseURL:   orDefault(cfg.BaseURL, defaultOllamaURL),
+			Model:     cfg.Model,
+			KeepAlive: cfg.KeepAlive,
+			Headers:   cfg.Headers,
+		}, nil
+	case "llamacpp":
+		// llama.cpp's server speaks the OpenAI chat completions protocol.
+		return &OpenAIClient{
+			APIKey:      cfg.APIKey,
+			BaseURL:     orDefault(cfg.BaseURL, defaultLlamaCppURL),
+			Model:       cfg.Model,
+			Headers:     cfg.Headers,
+			KeyOptional: true,
 		}, nil
 	case "":
 		return nil, fmt.Errorf("no LLM provider configured")
@@ -30,16 +93,225 @@ func NewLLMClient(cfg config.Config) (LLMClient, error) {
 	}
 }
 
+// defaultOpenAIURL is the public OpenAI API endpoint.
+const defaultOpenAIURL = "https://api.openai.com/v1"
+
+// defaultOpenAIModel is used by the OpenAI protocol clients when no model
+// is configured.
+const defaultOpenAIModel = "gpt-3.5-turbo"
+
+// ModelName returns the model the client for cfg requests: the configured
+// one, or the provider's default.
+func ModelName(cfg config.Config) string {
+	if cfg.Model != "" {
+		return cfg.Model
+	}
+	switch cfg.Provider {
+	case "anthropic":
+		return defaultAnthropicModel
+	case "ollama":
+		return defaultOllamaModel
+	default:
+		return defaultOpenAIModel
+	}
+}
+
+// defaultAzureAPIVersion is the Azure OpenAI API version used when none is configured.
+const defaultAzureAPIVersion = "2024-06-01"
+
+// orDefault returns value, or fallback when value is empty.
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// setHeaders adds the user-configured extra headers to req.
+func setHeaders(req *http.Request, headers map[string]string) {
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+}
+
+// systemPrompt is the system message shared by all providers.
+const systemPrompt = "You are a helpful assistant that generates git commit messages."
+
+// commitPrompt builds the user prompt asking for a commit message for the given diff.
+func commitPrompt(diff string, userMessage string) string {
+	return fmt.Sprintf(`You are an expert at writing conventional git commit messages.
+Based on the following user prompt and git diff, generate a concise and descriptive commit message.
+The message should follow the conventional commit format (e.g., 'feat: add new feature' or 'fix: resolve a bug').
+The first line should be a short summary (the title), followed by a blank line, and then a more detailed description (the body) if necessary.
+Do not include the 'Changes:' section with file stats in your output.
+
+User Prompt: "%s"
+
+Git Diff:
+%s`, userMessage, diff)
+}
+
+// SummarizeFileDiff asks client for a one or two sentence summary of the
+// change to a single file. It is used when a diff is too large to send whole.
+func SummarizeFileDiff(ctx context.Context, client LLMClient, path string, diff string) (string, error) {
+	prompt := fmt.Sprintf(`Summarise the following change to %s in one or two sentences.
+Describe what changed and why it matters, not the individual lines.
+Reply with the summary only.
+
+Git Diff:
+%s`, path, diff)
+	return client.Complete(ctx, "You are a helpful assistant that summarises code changes.", prompt)
+}
+
+// DraftPullRequest asks client for a pull request title and Markdown
+// description covering the commits in log and their combined diff. The
+// first line of the reply is the title, followed by a blank line and the
+// description.
+func DraftPullRequest(ctx context.Context, client LLMClient, log string, diff string) (string, error) {
+	prompt := fmt.Sprintf(`Write a pull request for the branch described below.
+The first line must be a concise title of at most 72 characters, without Markdown.
+Then leave a blank line and write the description in Markdown with these sections:
+## Summary - what the branch changes and why, as a short paragraph or bullet list.
+## Testing - how the change was or should be tested.
+## Risks - what could break, migrations, or anything reviewers should look at closely. Write "None identified." if there are none.
+Do not wrap the reply in a code block.
+
+Commits:
+%s
+
+Git Diff:
+%s`, log, diff)
+	return client.Complete(ctx, "You are a helpful assistant that writes pull request descriptions.", prompt)
+}
+
+// ExplainChanges asks client for a plain-language explanation of what the
+// commits in log did, based on their messages and combined diff. With more
+// than one commit the explanation is written as a single narrative.
+func ExplainChanges(ctx context.Context, client LLMClient, log string, diff string, commits int) (string, error) {
+	subject := "this commit"
+	shape := "Start with one sentence saying what the commit does, then explain how and why in a few short paragraphs."
+	if commits > 1 {
+		subject = fmt.Sprintf("this series of %d commits", commits)
+		shape = "Tell the story of the series as one narrative: the goal, how the commits build on each other, and where it ended up. Do not just list the commits."
+	}
+	prompt := fmt.Sprintf(`Explain %s to a developer who is new to the codebase.
+%s
+Mention the files and functions that matter, the reasoning behind the change where the messages or code reveal it, and anything surprising or risky.
+Use plain language and avoid restating the diff line by line.
+
+Commits:
+%s
+
+Git Diff:
+%s`, subject, shape, log, diff)
+	return client.Complete(ctx, "You are a senior engineer who explains code changes clearly.", prompt)
+}
+
+// SummarizeActivity asks client to turn a list of recent commits, grouped
+// by repository and branch, into a short status update for a standup.
+func SummarizeActivity(ctx context.Context, client LLMClient, activity string) (string, error) {
+	prompt := fmt.Sprintf(`Turn the following list of my recent commits into a short status update for a daily standup.
+Write at most five Markdown bullets in the first person past tense, grouping related commits into one bullet and naming the repository when there is more than one.
+Focus on outcomes rather than individual commits, and reply with the bullets only.
+
+Commits:
+%s`, activity)
+	return client.Complete(ctx, "You are a helpful assistant that writes concise status updates.", prompt)
+}
+
+// ClusterHunks asks client to group numbered hunks into logical commits.
+// The reply is JSON of the form {"groups": [{"name": "...", "hunks": [1, 2]}]}.
+func ClusterHunks(ctx context.Context, client LLMClient, hunks string) (string, error) {
+	prompt := fmt.Sprintf(`The following numbered hunks are staged in a git repository. Group them into logical commits, so that each commit makes one coherent change that a reviewer would expect to see on its own (for example a feature with its tests, a refactoring, or a documentation update).
+Use as few groups as make sense; a single group is fine if everything belongs together.
+Reply with a JSON object and nothing else, in exactly this form:
+{"groups": [{"name": "short description of the commit", "hunks": [1, 2]}]}
+Every hunk number must appear in exactly one group.
+
+%s`, hunks)
+	return client.Complete(ctx, "You are an expert at organising changes into clean git history.", prompt)
+}
+
+// ReviewDiff asks client to review a diff and reply with JSON findings.
+// Lines in diff may be prefixed with their number in the new file. When a
+// previous reply was rejected, feedback explains why so the model can fix it.
+func ReviewDiff(ctx context.Context, client LLMClient, diff string, feedback string) (string, error) {
+	prompt := fmt.Sprintf(`Review the following change like an experienced colleague would before it is committed.
+Look for bugs, security problems, unhandled errors, race conditions and confusing code. Ignore pure style preferences.
+Reply with a JSON object and nothing else, in exactly this form:
+{"findings": [{"file": "path/to/file", "line": 42, "severity": "error", "message": "what is wrong and how to fix it"}]}
+"file" must be one of the files in the diff, "line" a line number in the new version of the file, and "severity" one of "error", "warning" or "info".
+Reply with {"findings": []} if there is nothing worth mentioning.
+
+Diff:
+%s`, diff)
+	if feedback != "" {
+		prompt += "\n\nYour previous reply was rejected:\n" + feedback + "\nReply again with corrected JSON."
+	}
+	return client.Complete(ctx, "You are a meticulous code reviewer.", prompt)
+}
+
+// PolishChangelogEntries asks client to rewrite changelog entries into
+// plain, user-facing language. The reply must have exactly one line per
+// entry, in order; otherwise an error is returned and callers should keep
+// the original entries.
+func PolishChangelogEntries(ctx context.Context, client LLMClient, entries []string) ([]string, error) {
+	var list strings.Builder
+	for i, entry := range entries {
+		fmt.Fprintf(&list, "%d. %s\n", i+1, entry)
+	}
+	prompt := fmt.Sprintf(`Rewrite each of the following changelog entries so that users of the software, not its developers, understand what changed.
+Keep each entry to one short sentence fragment starting with a lower-case verb, and keep issue numbers and identifiers.
+Reply with exactly %d numbered lines in the same order, in the form "1. entry", and nothing else.
+
+%s`, len(entries), list.String())
+
+	reply, err := client.Complete(ctx, "You are a helpful assistant that writes release notes.", prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var polished []string
+	for _, line := range strings.Split(strings.TrimSpace(reply), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		number, text, ok := strings.Cut(line, ". ")
+		if !ok || number != strconv.Itoa(len(polished)+1) {
+			return nil, fmt.Errorf("unexpected line in polished changelog: %q", line)
+		}
+		polished = append(polished, strings.TrimSpace(text))
+	}
+	if len(polished) != len(entries) {
+		return nil, fmt.Errorf("polished changelog has %d entries, want %d", len(polished), len(entries))
+	}
+	return polished, nil
+}
+
 // OpenAIClient is a client for the OpenAI API.
 type OpenAIClient struct {
 	APIKey  string
 	BaseURL string
+	// Model is the chat model to use. Defaults to defaultOpenAIModel when empty.
+	Model string
+	// Headers are extra HTTP headers sent with every request, e.g. for gateways.
+	Headers map[string]string
+	// APIVersion is sent as the api-version query parameter when set.
+	APIVersion string
+	// Azure switches authentication to the api-key header used by Azure
+	// OpenAI deployments instead of a bearer token.
+	Azure bool
+	// KeyOptional allows requests without an API key, for local servers
+	// that do not authenticate.
+	KeyOptional bool
 }
 
 // openAIRequest represents the request body for the OpenAI Chat Completions API.
 type openAIRequest struct {
 	Model    string    `json:"model"`
 	Messages []message `json:"messages"`
+	Stream   bool      `json:"stream,omitempty"`
 }
 
 // message is a single message in the chat history.
@@ -55,75 +327,175 @@ type openAIResponse struct {
 			Content string `json:"content"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage *openAIUsage `json:"usage,omitempty"`
 	Error *struct {
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
 }
 
+// openAIUsage is the token usage of an OpenAI request. Streamed responses
+// only include it if the server sends it unasked.
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// openAIStreamChunk is a single server-sent event of a streamed OpenAI response.
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage,omitempty"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// chatCompletionsURL returns the chat completions endpoint for the client,
+// including the api-version query parameter when one is configured.
+func (c *OpenAIClient) chatCompletionsURL() (string, error) {
+	u, err := url.Parse(strings.TrimSuffix(c.BaseURL, "/") + "/chat/completions")
+	if err != nil {
+		return "", fmt.Errorf("invalid OpenAI base URL %q: %w", c.BaseURL, err)
+	}
+	if c.APIVersion != "" {
+		q := u.Query()
+		q.Set("api-version", c.APIVersion)
+		u.RawQuery = q.Encode()
+	}
+	return u.String(), nil
+}
+
 // GenerateCommitMessage generates a commit message using the OpenAI API.
 func (c *OpenAIClient) GenerateCommitMessage(diff string, userMessage string) (string, error) {
-	if c.APIKey == "" {
-		return "", fmt.Errorf("OpenAI API key is not set")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return c.Complete(ctx, systemPrompt, commitPrompt(diff, userMessage))
+}
+
+// StreamCommitMessage generates a commit message using the OpenAI API,
+// passing each token to onToken as it arrives over server-sent events.
+func (c *OpenAIClient) StreamCommitMessage(ctx context.Context, diff string, userMessage string, onToken func(string)) (string, error) {
+	resp, err := c.send(ctx, systemPrompt, commitPrompt(diff, userMessage), true)
+	if err != nil {
+		return "", err
 	}
+	defer resp.Body.Close()
 
-	prompt := fmt.Sprintf(`You are an expert at writing conventional git commit messages.
-Based on the following user prompt and git diff, generate a concise and descriptive commit message.
-The message should follow the conventional commit format (e.g., 'feat: add new feature' or 'fix: resolve a bug').
-The first line should be a short summary (the title), followed by a blank line, and then a more detailed description (the body) if necessary.
-Do not include the 'Changes:' section with file stats in your output.
+	var text strings.Builder
+	err = readSSE(resp.Body, func(event string, data string) (bool, error) {
+		if data == "[DONE]" {
+			return true, nil
+		}
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, fmt.Errorf("could not decode OpenAI stream: %w", err)
+		}
+		if chunk.Error != nil {
+			return false, fmt.Errorf("OpenAI API error: %s", chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			recordUsage(ctx, chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens)
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			text.WriteString(chunk.Choices[0].Delta.Content)
+			onToken(chunk.Choices[0].Delta.Content)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no commit message generated by OpenAI")
+	}
+	return text.String(), nil
+}
 
-User Prompt: "%s"
+// Complete sends a chat completion request to the OpenAI API.
+func (c *OpenAIClient) Complete(ctx context.Context, system string, prompt string) (string, error) {
+	resp, err := c.send(ctx, system, prompt, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
 
-Git Diff:
-%s`, userMessage, diff)
+	var apiResp openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return "", fmt.Errorf("could not decode OpenAI response: %w", err)
+	}
+	if apiResp.Usage != nil {
+		recordUsage(ctx, apiResp.Usage.PromptTokens, apiResp.Usage.CompletionTokens)
+	}
+
+	if len(apiResp.Choices) == 0 {
+		return "", fmt.Errorf("no response generated by OpenAI")
+	}
+
+	return apiResp.Choices[0].Message.Content, nil
+}
+
+// send posts a chat completion request and returns the response once the
+// server has accepted it. Error responses are decoded and returned as errors.
+func (c *OpenAIClient) send(ctx context.Context, system string, prompt string, stream bool) (*http.Response, error) {
+	if c.APIKey == "" && !c.KeyOptional {
+		return nil, fmt.Errorf("OpenAI API key is not set")
+	}
+
+	model := c.Model
+	if model == "" {
+		model = defaultOpenAIModel
+	}
 
 	reqBody := openAIRequest{
-		Model: "gpt-3.5-turbo", // A common and effective model
+		Model: model,
 		Messages: []message{
-			{Role: "system", Content: "You are a helpful assistant that generates git commit messages."},
+			{Role: "system", Content: system},
 			{Role: "user", Content: prompt},
 		},
+		Stream: stream,
 	}
 
 	reqBytes, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("could not marshal OpenAI request: %w", err)
+		return nil, fmt.Errorf("could not marshal OpenAI request: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	requestURL := fmt.Sprintf("%s/chat/completions", c.BaseURL)
+	requestURL, err := c.chatCompletionsURL()
+	if err != nil {
+		return nil, err
+	}
 	req, err := http.NewRequestWithContext(ctx, "POST", requestURL, bytes.NewBuffer(reqBytes))
 	if err != nil {
-		return "", fmt.Errorf("could not create OpenAI request: %w", err)
+		return nil, fmt.Errorf("could not create OpenAI request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	if c.APIKey != "" {
+		if c.Azure {
+			req.Header.Set("api-key", c.APIKey)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+c.APIKey)
+		}
+	}
+	setHeaders(req, c.Headers)
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("could not send request to OpenAI: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var apiResp openAIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return "", fmt.Errorf("could not decode OpenAI response: %w", err)
+		return nil, fmt.Errorf("could not send request to OpenAI: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		if apiResp.Error != nil {
-			return "", fmt.Errorf("OpenAI API error (%s): %s", resp.Status, apiResp.Error.Message)
+		defer resp.Body.Close()
+		var apiResp openAIResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err == nil && apiResp.Error != nil {
+			return nil, fmt.Errorf("OpenAI API error (%s): %s", resp.Status, apiResp.Error.Message)
 		}
-		return "", fmt.Errorf("OpenAI API request failed with status: %s", resp.Status)
+		return nil, fmt.Errorf("OpenAI API request failed with status: %s", resp.Status)
 	}
 
-	if len(apiResp.Choices) == 0 {
-		return "", fmt.Errorf("no commit message generated by OpenAI")
-	}
-
-	return apiResp.Choices[0].Message.Content, nil
+	return resp, nil
 }
@@ -0,0 +1,166 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultOllamaURL is the address a stock Ollama install listens on.
+const defaultOllamaURL = "http://localhost:11434"
+
+// defaultOllamaModel is used when no model is configured.
+const defaultOllamaModel = "llama3.2"
+
+// defaultLlamaCppURL is the OpenAI-compatible endpoint of a stock llama.cpp server.
+const defaultLlamaCppURL = "http://localhost:8080/v1"
+
+// OllamaClient is a client for a local Ollama server.
+// It needs no API key and never leaves the configured host.
+type OllamaClient struct {
+	BaseURL string
+	// Model is the local model to use. Defaults to defaultOllamaModel when empty.
+	Model string
+	// KeepAlive controls how long Ollama keeps the model loaded after the
+	// request (e.g. "5m", "1h" or "-1" for forever). Empty uses the server default.
+	KeepAlive string
+	// Headers are extra HTTP headers sent with every request, e.g. for a proxy.
+	Headers map[string]string
+}
+
+// ollamaRequest represents the request body for the Ollama chat API.
+type ollamaRequest struct {
+	Model     string    `json:"model"`
+	Messages  []message `json:"messages"`
+	Stream    bool      `json:"stream"`
+	KeepAlive string    `json:"keep_alive,omitempty"`
+}
+
+// ollamaResponse is the response from the Ollama chat API.
+type ollamaResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done bool `json:"done"`
+	// PromptEvalCount and EvalCount are the input and output token counts,
+	// sent with the final response.
+	PromptEvalCount int    `json:"prompt_eval_count,omitempty"`
+	EvalCount       int    `json:"eval_count,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// GenerateCommitMessage generates a commit message using a local Ollama model.
+func (c *OllamaClient) GenerateCommitMessage(diff string, userMessage string) (string, error) {
+	// Local models can take a while to load and run, so allow more time than the hosted APIs.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	return c.Complete(ctx, systemPrompt, commitPrompt(diff, userMessage))
+}
+
+// StreamCommitMessage generates a commit message using a local Ollama
+// model, passing each token to onToken as the NDJSON stream delivers it.
+func (c *OllamaClient) StreamCommitMessage(ctx context.Context, diff string, userMessage string, onToken func(string)) (string, error) {
+	resp, err := c.send(ctx, systemPrompt, commitPrompt(diff, userMessage), true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	err = readNDJSON(resp.Body, func(line []byte) (bool, error) {
+		var chunk ollamaResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return false, fmt.Errorf("could not decode Ollama stream: %w", err)
+		}
+		if chunk.Error != "" {
+			return false, fmt.Errorf("Ollama API error: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			text.WriteString(chunk.Message.Content)
+			onToken(chunk.Message.Content)
+		}
+		recordUsage(ctx, chunk.PromptEvalCount, chunk.EvalCount)
+		return chunk.Done, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no commit message generated by Ollama")
+	}
+	return text.String(), nil
+}
+
+// Complete sends a single-turn request to the Ollama chat API.
+func (c *OllamaClient) Complete(ctx context.Context, system string, prompt string) (string, error) {
+	resp, err := c.send(ctx, system, prompt, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var apiResp ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return "", fmt.Errorf("could not decode Ollama response: %w", err)
+	}
+	recordUsage(ctx, apiResp.PromptEvalCount, apiResp.EvalCount)
+
+	if apiResp.Message.Content == "" {
+		return "", fmt.Errorf("no response generated by Ollama")
+	}
+
+	return apiResp.Message.Content, nil
+}
+
+// send posts a chat request to Ollama and returns the response once the
+// server has accepted it. Error responses are decoded and returned as errors.
+func (c *OllamaClient) send(ctx context.Context, system string, prompt string, stream bool) (*http.Response, error) {
+	model := c.Model
+	if model == "" {
+		model = defaultOllamaModel
+	}
+
+	reqBody := ollamaRequest{
+		Model: model,
+		Messages: []message{
+			{Role: "system", Content: system},
+			{Role: "user", Content: prompt},
+		},
+		Stream:    stream,
+		KeepAlive: c.KeepAlive,
+	}
+
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal Ollama request: %w", err)
+	}
+
+	requestURL := fmt.Sprintf("%s/api/chat", c.BaseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", requestURL, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, fmt.Errorf("could not create Ollama request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setHeaders(req, c.Headers)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not send request to Ollama at %s: %w", c.BaseURL, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var apiResp ollamaResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err == nil && apiResp.Error != "" {
+			return nil, fmt.Errorf("Ollama API error (%s): %s", resp.Status, apiResp.Error)
+		}
+		return nil, fmt.Errorf("Ollama API request failed with status: %s", resp.Status)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,49 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/biswajitpain/gitter/internal/redact"
+)
+
+// RedactingClient wraps an LLMClient and masks likely secrets in every diff,
+// hint and prompt before it is sent, so that keys staged by accident do not
+// leave the machine.
+type RedactingClient struct {
+	Client   LLMClient
+	Redactor *redact.Redactor
+	// OnRedact, if set, is called with what was masked whenever a request
+	// contained secrets.
+	OnRedact func(matches []redact.Match)
+}
+
+// redact masks the secrets in each of texts, reporting them all at once.
+func (c *RedactingClient) redact(texts ...*string) {
+	var all []redact.Match
+	for _, text := range texts {
+		var matches []redact.Match
+		*text, matches = c.Redactor.Redact(*text)
+		all = append(all, matches...)
+	}
+	if len(all) > 0 && c.OnRedact != nil {
+		c.OnRedact(all)
+	}
+}
+
+// GenerateCommitMessage masks secrets and calls the wrapped client.
+func (c *RedactingClient) GenerateCommitMessage(diff string, userMessage string) (string, error) {
+	c.redact(&diff, &userMessage)
+	return c.Client.GenerateCommitMessage(diff, userMessage)
+}
+
+// StreamCommitMessage masks secrets and calls the wrapped client.
+func (c *RedactingClient) StreamCommitMessage(ctx context.Context, diff string, userMessage string, onToken func(string)) (string, error) {
+	c.redact(&diff, &userMessage)
+	return c.Client.StreamCommitMessage(ctx, diff, userMessage, onToken)
+}
+
+// Complete masks secrets and calls the wrapped client.
+func (c *RedactingClient) Complete(ctx context.Context, system string, prompt string) (string, error) {
+	c.redact(&prompt)
+	return c.Client.Complete(ctx, system, prompt)
+}
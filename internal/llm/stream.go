@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxStreamLine is the longest single line accepted from a streaming response.
+const maxStreamLine = 1024 * 1024
+
+// readSSE reads a server-sent event stream from r and calls handle with the
+// event name and data of each event. It stops when handle reports done, when
+// handle fails, or at the end of the stream.
+func readSSE(r io.Reader, handle func(event string, data string) (bool, error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+
+	var event string
+	var data []string
+	dispatch := func() (bool, error) {
+		if len(data) == 0 {
+			event = ""
+			return false, nil
+		}
+		done, err := handle(event, strings.Join(data, "\n"))
+		event, data = "", nil
+		return done, err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if done, err := dispatch(); done || err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, ":"):
+			// Comment lines keep the connection alive.
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("could not read event stream: %w", err)
+	}
+	_, err := dispatch()
+	return err
+}
+
+// readNDJSON calls handle with each non-empty line of a newline-delimited
+// JSON stream until handle reports done or fails, or the stream ends.
+func readNDJSON(r io.Reader, handle func(line []byte) (bool, error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		if done, err := handle(line); done || err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("could not read stream: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package llm
+
+import "context"
+
+// Usage is the number of tokens a request used, as reported by the
+// provider. Providers that do not report usage leave it at zero.
+type Usage struct {
+	InputTokens  int
+	OutputTokens int
+}
+
+// usageKey is the context key under which WithUsage stores its Usage.
+type usageKey struct{}
+
+// WithUsage returns a context in which a client adds the tokens its
+// request used to u. The context must not be shared by concurrent requests.
+func WithUsage(ctx context.Context, u *Usage) context.Context {
+	return context.WithValue(ctx, usageKey{}, u)
+}
+
+// recordUsage adds input and output tokens to the Usage registered in ctx
+// with WithUsage, if any. Streamed responses may report them in parts.
+func recordUsage(ctx context.Context, input, output int) {
+	if u, ok := ctx.Value(usageKey{}).(*Usage); ok {
+		u.InputTokens += input
+		u.OutputTokens += output
+	}
+}
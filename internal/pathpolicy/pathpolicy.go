@@ -0,0 +1,252 @@
+// Package pathpolicy decides which files may be sent to an LLM. Files are
+// withheld when they match the repository's .gitterignore file, which uses
+// gitignore syntax, or the exclude globs in the config, or when include
+// globs are configured and they match none of them.
+package pathpolicy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/biswajitpain/gitter/internal/diff"
+)
+
+// IgnoreFile is the name of the ignore file at the top of the work tree.
+const IgnoreFile = ".gitterignore"
+
+// pattern is one line of an ignore file.
+type pattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher matches paths against patterns in gitignore syntax.
+type Matcher struct {
+	patterns []pattern
+}
+
+// ParseMatcher parses lines in gitignore syntax: blank lines and lines
+// starting with "#" are skipped, "!" negates a pattern, a trailing "/"
+// matches only directories, and a pattern containing a "/" other than at
+// the end is relative to the top of the work tree. "*", "?", "[...]" and
+// "**" work as in .gitignore.
+func ParseMatcher(lines []string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var p pattern
+		if rest, ok := strings.CutPrefix(line, "!"); ok {
+			p.negate = true
+			line = rest
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if rest, ok := strings.CutSuffix(line, "/"); ok {
+			p.dirOnly = true
+			line = rest
+		}
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+
+		expr := globToRegexp(line)
+		if !anchored {
+			expr = "(?:.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", line, err)
+		}
+		p.re = re
+		m.patterns = append(m.patterns, p)
+	}
+	return m, nil
+}
+
+// globToRegexp translates a gitignore glob into a regular expression.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**") && i+2 == len(glob):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if rest, ok := strings.CutPrefix(class, "!"); ok {
+				class = "^" + rest
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// match returns whether the last pattern matching p, a directory if isDir,
+// excludes it, and whether any pattern matched at all.
+func (m *Matcher) match(p string, isDir bool) (excluded, matched bool) {
+	for _, pat := range m.patterns {
+		if pat.dirOnly && !isDir {
+			continue
+		}
+		if pat.re.MatchString(p) {
+			excluded, matched = !pat.negate, true
+		}
+	}
+	return excluded, matched
+}
+
+// Match reports whether the file at path, relative to the top of the work
+// tree, is matched. As in git, a file inside a matched directory is matched
+// even if a later pattern negates the file itself.
+func (m *Matcher) Match(path string) bool {
+	dirs := strings.Split(path, "/")
+	for i := 1; i < len(dirs); i++ {
+		if excluded, _ := m.match(strings.Join(dirs[:i], "/"), true); excluded {
+			return true
+		}
+	}
+	excluded, _ := m.match(path, false)
+	return excluded
+}
+
+// Policy decides which files may be sent to an LLM.
+type Policy struct {
+	ignore  *Matcher
+	include *Matcher
+	exclude *Matcher
+}
+
+// New returns a Policy from the lines of an ignore file and include and
+// exclude globs, all in gitignore syntax.
+func New(ignore, include, exclude []string) (*Policy, error) {
+	p := &Policy{}
+	var err error
+	if p.ignore, err = ParseMatcher(ignore); err != nil {
+		return nil, fmt.Errorf("%s: %w", IgnoreFile, err)
+	}
+	if p.include, err = ParseMatcher(include); err != nil {
+		return nil, fmt.Errorf("include globs: %w", err)
+	}
+	if p.exclude, err = ParseMatcher(exclude); err != nil {
+		return nil, fmt.Errorf("exclude globs: %w", err)
+	}
+	return p, nil
+}
+
+// Load returns the Policy for the work tree at root, reading its
+// .gitterignore file if there is one.
+func Load(root string, include, exclude []string) (*Policy, error) {
+	var lines []string
+	data, err := os.ReadFile(filepath.Join(root, IgnoreFile))
+	if err == nil {
+		lines = strings.Split(string(data), "\n")
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not read %s: %w", IgnoreFile, err)
+	}
+	return New(lines, include, exclude)
+}
+
+// Empty reports whether the policy allows every file.
+func (p *Policy) Empty() bool {
+	return len(p.ignore.patterns) == 0 && len(p.include.patterns) == 0 && len(p.exclude.patterns) == 0
+}
+
+// Allowed reports whether the file at path may be sent to an LLM.
+func (p *Policy) Allowed(path string) bool {
+	if len(p.include.patterns) > 0 && !p.include.Match(path) {
+		return false
+	}
+	return !p.ignore.Match(path) && !p.exclude.Match(path)
+}
+
+// AllowedFile reports whether the diff of file may be sent to an LLM. A
+// renamed or copied file is withheld if either of its paths is.
+func (p *Policy) AllowedFile(file *diff.File) bool {
+	for _, path := range []string{file.OldPath, file.NewPath} {
+		if path != "" && !p.Allowed(path) {
+			return false
+		}
+	}
+	return true
+}
+
+// Split divides files into those that may be sent and those withheld.
+func (p *Policy) Split(files []*diff.File) (allowed, withheld []*diff.File) {
+	for _, file := range files {
+		if p.AllowedFile(file) {
+			allowed = append(allowed, file)
+		} else {
+			withheld = append(withheld, file)
+		}
+	}
+	return allowed, withheld
+}
+
+// Filter removes the withheld files from diffText, returning the rest of
+// the diff and the withheld files. A diff that cannot be parsed cannot be
+// checked, so it is an error unless the policy is empty.
+func (p *Policy) Filter(diffText string) (string, []*diff.File, error) {
+	if p.Empty() {
+		return diffText, nil, nil
+	}
+	files, err := diff.Parse(diffText)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not check the diff against the path policy: %w", err)
+	}
+	allowed, withheld := p.Split(files)
+	if len(withheld) == 0 {
+		return diffText, nil, nil
+	}
+	var b strings.Builder
+	for _, file := range allowed {
+		b.WriteString(file.String())
+	}
+	return b.String(), withheld, nil
+}
+
+// Note lists withheld files with their size, one line each, to append to
+// a prompt in place of their diffs. It is empty if there are none.
+func Note(withheld []*diff.File) string {
+	if len(withheld) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\nFiles withheld by path policy (diff not shared):\n")
+	for _, file := range withheld {
+		if file.Binary {
+			fmt.Fprintf(&b, "- %s (%s, binary)\n", file.Path(), file.Change)
+			continue
+		}
+		fmt.Fprintf(&b, "- %s (%s, +%d -%d)\n", file.Path(), file.Change, file.Added(), file.Removed())
+	}
+	return b.String()
+}
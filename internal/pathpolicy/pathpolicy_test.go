@@ -0,0 +1,164 @@
+package pathpolicy_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/biswajitpain/gitter/internal/pathpolicy"
+)
+
+func TestMatcher(t *testing.T) {
+	m, err := pathpolicy.ParseMatcher([]string{
+		"# NDA code",
+		"vendor/acme/",
+		"/secret.txt",
+		"*.key",
+		"!public.key",
+		"docs/**/internal",
+		"build[0-9]",
+	})
+	if err != nil {
+		t.Fatalf("ParseMatcher() error: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"vendor/acme/lib.go", true},
+		{"vendor/acme/sub/x.go", true},
+		{"src/vendor/acme/lib.go", false}, // a slash anchors the pattern
+		{"vendor/acme", false},            // a file, not the directory
+		{"vendor/other/lib.go", false},
+		{"secret.txt", true},
+		{"sub/secret.txt", false},
+		{"certs/server.key", true},
+		{"public.key", false},
+		{"docs/internal", true},
+		{"docs/a/b/internal", true},
+		{"docs/internals", false},
+		{"build7", true},
+		{"buildx", false},
+		{"main.go", false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.path); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher_NegatedFileInIgnoredDirectory(t *testing.T) {
+	m, err := pathpolicy.ParseMatcher([]string{"nda/", "!nda/README.md"})
+	if err != nil {
+		t.Fatalf("ParseMatcher() error: %v", err)
+	}
+	if !m.Match("nda/README.md") {
+		t.Error("a file in an ignored directory should stay ignored, as in git")
+	}
+}
+
+func TestPolicy_Allowed(t *testing.T) {
+	p, err := pathpolicy.New([]string{"nda/"}, []string{"src/", "*.md"}, []string{"src/gen/"})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"src/main.go", true},
+		{"README.md", true},
+		{"src/gen/api.go", false}, // excluded
+		{"nda/README.md", false},  // ignored
+		{"scripts/run.sh", false}, // not included
+	}
+	for _, tt := range tests {
+		if got := p.Allowed(tt.path); got != tt.want {
+			t.Errorf("Allowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+const twoFiles = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1 +1,2 @@
+ package main
++// hello
+diff --git a/nda/partner.go b/nda/partner.go
+index 1111111..2222222 100644
+--- a/nda/partner.go
++++ b/nda/partner.go
+@@ -1,2 +1,3 @@
+ package nda
+-var rate = 1
++var rate = 2
++var margin = 3
+`
+
+func TestPolicy_Filter(t *testing.T) {
+	p, err := pathpolicy.New([]string{"nda/"}, nil, nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	got, withheld, err := p.Filter(twoFiles)
+	if err != nil {
+		t.Fatalf("Filter() error: %v", err)
+	}
+	if !strings.Contains(got, "+// hello") || strings.Contains(got, "nda/") || strings.Contains(got, "rate") {
+		t.Errorf("Filter() should keep main.go and drop nda/partner.go:\n%s", got)
+	}
+	if len(withheld) != 1 || withheld[0].Path() != "nda/partner.go" {
+		t.Fatalf("Filter() withheld = %v, want nda/partner.go", withheld)
+	}
+
+	want := "\nFiles withheld by path policy (diff not shared):\n- nda/partner.go (modified, +2 -1)\n"
+	if note := pathpolicy.Note(withheld); note != want {
+		t.Errorf("Note() = %q, want %q", note, want)
+	}
+}
+
+func TestPolicy_FilterRename(t *testing.T) {
+	text := `diff --git a/nda/old.go b/pub/new.go
+similarity index 90%
+rename from nda/old.go
+rename to pub/new.go
+`
+	p, err := pathpolicy.New([]string{"nda/"}, nil, nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	_, withheld, err := p.Filter(text)
+	if err != nil {
+		t.Fatalf("Filter() error: %v", err)
+	}
+	if len(withheld) != 1 {
+		t.Errorf("a file renamed out of an ignored directory should be withheld, got %v", withheld)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, pathpolicy.IgnoreFile), []byte("nda/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := pathpolicy.Load(root, nil, []string{"*.pem"})
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if p.Allowed("nda/x.go") || p.Allowed("certs/a.pem") || !p.Allowed("main.go") {
+		t.Error("Load() should combine .gitterignore with the exclude globs")
+	}
+
+	p, err = pathpolicy.Load(t.TempDir(), nil, nil)
+	if err != nil {
+		t.Fatalf("Load() without an ignore file error: %v", err)
+	}
+	if !p.Empty() {
+		t.Error("Load() without an ignore file or globs should return an empty policy")
+	}
+}
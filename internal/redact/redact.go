@@ -0,0 +1,290 @@
+// Package redact finds likely secrets, such as cloud keys, tokens, private
+// keys and other high-entropy strings, in text and diffs, and masks them
+// before the text is sent to a third party.
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/biswajitpain/gitter/internal/budget"
+	"github.com/biswajitpain/gitter/internal/diff"
+)
+
+// Rule detects one kind of secret. If Pattern has a capture group, only
+// the first group is the secret; otherwise the whole match is.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Check, if set, must also accept the secret for it to count.
+	Check func(secret string) bool
+}
+
+// EntropyRule is the name of the rule for high-entropy strings, which is
+// not applied to lockfiles and generated files, where hashes are expected.
+const EntropyRule = "high-entropy string"
+
+// DefaultRules are the built-in rules.
+var DefaultRules = []Rule{
+	{Name: "private key", Pattern: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----(?s:.*?)(?:-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----|\z)`)},
+	{Name: "AWS access key", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`)},
+	{Name: "GitHub token", Pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{Name: "GitLab token", Pattern: regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20,}\b`)},
+	{Name: "Slack token", Pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{Name: "Google API key", Pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{Name: "Stripe key", Pattern: regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}\b`)},
+	{Name: "LLM API key", Pattern: regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_\-]{32,}`)},
+	{Name: "JWT", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{10,}\.eyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}`)},
+	{Name: "URL credentials", Pattern: regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.\-]*://[^\s:/@"']+:([^\s@/"']{3,})@`), Check: notMasked},
+	{
+		Name:    "credential assignment",
+		Pattern: regexp.MustCompile(`(?i)(?:password|passwd|pwd|secret|token|api[_\-]?key|access[_\-]?key|client[_\-]?secret)["']?\s*[:=]\s*["']?([^\s"'` + "`" + `,;]{8,})`),
+		Check:   looksRandom,
+	},
+	{Name: EntropyRule, Pattern: regexp.MustCompile(`[A-Za-z0-9+/]{32,}={0,2}`), Check: isHighEntropy},
+}
+
+// publicBlock matches PEM blocks that are safe to publish.
+var publicBlock = regexp.MustCompile(`-----BEGIN (?:CERTIFICATE|PUBLIC KEY|[A-Z]+ PUBLIC KEY|PGP PUBLIC KEY BLOCK|X509 CRL)-----(?s:.*?)(?:-----END [A-Z0-9 ]+-----|\z)`)
+
+// Redactor finds and masks secrets using a set of rules.
+type Redactor struct {
+	rules []Rule
+}
+
+// New returns a Redactor using DefaultRules and the given extra regular
+// expressions, which are reported as "custom pattern".
+func New(patterns []string) (*Redactor, error) {
+	rules := append([]Rule{}, DefaultRules...)
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		rules = append(rules, Rule{Name: "custom pattern", Pattern: re})
+	}
+	return &Redactor{rules: rules}, nil
+}
+
+// Match is a secret found in a text, on the given 1-based line.
+type Match struct {
+	Rule string
+	Line int
+}
+
+// span is the position of a secret in a text.
+type span struct {
+	start, end int
+	rule       string
+}
+
+// find returns the non-overlapping secrets in text, in order. Rules named
+// in skip are not applied.
+func (r *Redactor) find(text string, skip map[string]bool) []span {
+	var spans []span
+	for _, rule := range r.rules {
+		if skip[rule.Name] {
+			continue
+		}
+		for _, loc := range rule.Pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			if start == end || (rule.Check != nil && !rule.Check(text[start:end])) {
+				continue
+			}
+			spans = append(spans, span{start: start, end: end, rule: rule.Name})
+		}
+	}
+
+	// Public certificates and keys are long base64 blocks but no secret.
+	for _, loc := range publicBlock.FindAllStringIndex(text, -1) {
+		spans = slices.DeleteFunc(spans, func(s span) bool {
+			return s.rule == EntropyRule && s.start >= loc[0] && s.end <= loc[1]
+		})
+	}
+
+	// Earlier rules are more specific, so on overlap the first match wins.
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var kept []span
+	for _, s := range spans {
+		if len(kept) > 0 && s.start < kept[len(kept)-1].end {
+			continue
+		}
+		kept = append(kept, s)
+	}
+	return kept
+}
+
+// Redact returns text with every secret replaced by "[REDACTED <rule>]",
+// together with what was masked.
+func (r *Redactor) Redact(text string) (string, []Match) {
+	spans := r.find(text, nil)
+	if len(spans) == 0 {
+		return text, nil
+	}
+
+	var b strings.Builder
+	matches := make([]Match, 0, len(spans))
+	last := 0
+	for _, s := range spans {
+		b.WriteString(text[last:s.start])
+		fmt.Fprintf(&b, "[REDACTED %s]", s.rule)
+		last = s.end
+		matches = append(matches, Match{Rule: s.rule, Line: strings.Count(text[:s.start], "\n") + 1})
+	}
+	b.WriteString(text[last:])
+	return b.String(), matches
+}
+
+// Finding is a secret added by a diff, at a line of the new file.
+type Finding struct {
+	File string
+	Line int
+	Rule string
+}
+
+// ScanDiff returns the secrets on the lines files add. Lines that are
+// removed or only context are not reported, since committing the diff
+// does not publish them anew.
+func (r *Redactor) ScanDiff(files []*diff.File) []Finding {
+	var findings []Finding
+	for _, file := range files {
+		var skip map[string]bool
+		if budget.IsGenerated(file.Path(), file.String()) {
+			skip = map[string]bool{EntropyRule: true}
+		}
+		for _, hunk := range file.Hunks {
+			var added []string
+			var numbers []int
+			line := hunk.NewStart
+			for _, l := range hunk.Lines {
+				switch l.Kind {
+				case diff.Addition:
+					added = append(added, l.Content)
+					numbers = append(numbers, line)
+					line++
+				case diff.Context:
+					line++
+				}
+			}
+
+			text := strings.Join(added, "\n")
+			for _, s := range r.find(text, skip) {
+				n := strings.Count(text[:s.start], "\n")
+				findings = append(findings, Finding{File: file.Path(), Line: numbers[n], Rule: s.rule})
+			}
+		}
+	}
+	return findings
+}
+
+// ScanAddedLines returns the secrets on the lines starting with "+" in a
+// diff that could not be parsed into files. Findings have no file, and Line
+// is the line of the diff the secret is on.
+func (r *Redactor) ScanAddedLines(text string) []Finding {
+	var added []string
+	var numbers []int
+	for i, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "+") {
+			added = append(added, line[1:])
+			numbers = append(numbers, i+1)
+		}
+	}
+
+	text = strings.Join(added, "\n")
+	var findings []Finding
+	for _, s := range r.find(text, nil) {
+		n := strings.Count(text[:s.start], "\n")
+		findings = append(findings, Finding{Line: numbers[n], Rule: s.rule})
+	}
+	return findings
+}
+
+// looksRandom reports whether an assigned credential value looks like a
+// real secret rather than a placeholder, variable or function call.
+func looksRandom(value string) bool {
+	if strings.ContainsAny(value, "(){}[]<>$%") || identifierPath.MatchString(value) {
+		return false
+	}
+	h := entropy(value)
+	return (classes(value) >= 3 && h >= 3) || (len(value) >= 16 && h >= 3.5)
+}
+
+// notMasked reports whether value is not an already masked placeholder
+// such as "***" or "xxxx".
+func notMasked(value string) bool {
+	return strings.Trim(value, "*xX.") != ""
+}
+
+// identifierPath matches code references such as cfg.APIKey.
+var identifierPath = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)+$`)
+
+// isHighEntropy reports whether s looks like a random token: mixed upper
+// and lower case letters and digits, with high Shannon entropy. Hex strings
+// such as commit hashes have no upper case and are not reported.
+func isHighEntropy(s string) bool {
+	hasUpper := strings.IndexFunc(s, func(r rune) bool { return r >= 'A' && r <= 'Z' }) >= 0
+	hasLower := strings.IndexFunc(s, func(r rune) bool { return r >= 'a' && r <= 'z' }) >= 0
+	hasDigit := strings.IndexFunc(s, func(r rune) bool { return r >= '0' && r <= '9' }) >= 0
+	return hasUpper && hasLower && hasDigit && entropy(s) >= 4.3 && !camelCase(s)
+}
+
+// camelCase reports whether s reads like a long identifier, such as
+// BenchmarkPriorityRFC9218: random tokens rarely have more than two lower
+// case letters in a row, identifiers are mostly made of such runs.
+func camelCase(s string) bool {
+	runs, letters, run := 0, 0, 0
+	for _, r := range s + " " {
+		if r >= 'a' && r <= 'z' {
+			run++
+			continue
+		}
+		if run > 0 {
+			runs++
+			letters += run
+		}
+		run = 0
+	}
+	return runs > 0 && letters >= 3*runs
+}
+
+// classes counts the character classes (upper case, lower case, digits and
+// other) used in s.
+func classes(s string) int {
+	var upper, lower, digit, other int
+	for _, r := range s {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = 1
+		case r >= 'a' && r <= 'z':
+			lower = 1
+		case r >= '0' && r <= '9':
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return upper + lower + digit + other
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -0,0 +1,218 @@
+// Package review turns a diff into an LLM code review prompt and validates
+// and renders the structured findings that come back.
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/biswajitpain/gitter/internal/diff"
+)
+
+// Severities are the allowed finding severities, most severe first.
+var Severities = []string{"error", "warning", "info"}
+
+// Finding is a single review comment on a line of the new version of a file.
+type Finding struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// response is the JSON document the model is asked to reply with.
+type response struct {
+	Findings []Finding `json:"findings"`
+}
+
+// FormatDiff renders files for the review prompt. Every added and context
+// line is prefixed with its line number in the new file, so the model can
+// point at exact lines; deleted lines have no number.
+func FormatDiff(files []*diff.File) string {
+	var b strings.Builder
+	for _, file := range files {
+		fmt.Fprintf(&b, "File: %s (%s)\n", file.Path(), file.Change)
+		if file.Binary {
+			b.WriteString("(binary file)\n\n")
+			continue
+		}
+		for _, hunk := range file.Hunks {
+			fmt.Fprintf(&b, "@@ %s\n", strings.TrimSpace(hunk.Section))
+			line := hunk.NewStart
+			for _, l := range hunk.Lines {
+				switch l.Kind {
+				case diff.Addition:
+					fmt.Fprintf(&b, "%6d + %s\n", line, l.Content)
+					line++
+				case diff.Context:
+					fmt.Fprintf(&b, "%6d   %s\n", line, l.Content)
+					line++
+				case diff.Deletion:
+					fmt.Fprintf(&b, "%6s - %s\n", "", l.Content)
+				}
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Parse extracts and validates the findings in a model reply. The reply
+// must be a JSON object {"findings": [...]}, optionally inside a Markdown
+// code block. Findings must name a file from files, a positive line and a
+// known severity; every problem found is reported in the error, which is
+// suitable for sending back to the model.
+func Parse(reply string, files []*diff.File) ([]Finding, error) {
+	text := strings.TrimSpace(reply)
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("the reply does not contain a JSON object")
+	}
+
+	var resp response
+	decoder := json.NewDecoder(strings.NewReader(text[start : end+1]))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&resp); err != nil {
+		return nil, fmt.Errorf("the reply is not valid findings JSON: %w", err)
+	}
+
+	paths := map[string]bool{}
+	for _, file := range files {
+		paths[file.Path()] = true
+	}
+
+	var problems []string
+	for i := range resp.Findings {
+		f := &resp.Findings[i]
+		f.Severity = strings.ToLower(strings.TrimSpace(f.Severity))
+		f.Message = strings.TrimSpace(f.Message)
+		switch {
+		case !paths[f.File]:
+			problems = append(problems, fmt.Sprintf("finding %d: file %q is not part of the diff", i+1, f.File))
+		case f.Line < 1:
+			problems = append(problems, fmt.Sprintf("finding %d: line must be a positive line number", i+1))
+		case severityRank(f.Severity) < 0:
+			problems = append(problems, fmt.Sprintf("finding %d: severity %q must be one of %s", i+1, f.Severity, strings.Join(Severities, ", ")))
+		case f.Message == "":
+			problems = append(problems, fmt.Sprintf("finding %d: message must not be empty", i+1))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid findings:\n%s", strings.Join(problems, "\n"))
+	}
+
+	sort.SliceStable(resp.Findings, func(i, j int) bool {
+		a, b := resp.Findings[i], resp.Findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return resp.Findings, nil
+}
+
+// severityRank returns the index of severity in Severities, or -1.
+func severityRank(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// Text renders findings grouped by file, one "file:line" reference per
+// finding, for terminals and editors that link such references.
+func Text(findings []Finding) string {
+	if len(findings) == 0 {
+		return "No findings.\n"
+	}
+	var b strings.Builder
+	current := ""
+	for _, f := range findings {
+		if f.File != current {
+			if current != "" {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "%s\n", f.File)
+			current = f.File
+		}
+		fmt.Fprintf(&b, "  %s:%d: %s: %s\n", f.File, f.Line, f.Severity, f.Message)
+	}
+	return b.String()
+}
+
+// sarifLevels maps severities to SARIF result levels.
+var sarifLevels = map[string]string{
+	"error":   "error",
+	"warning": "warning",
+	"info":    "note",
+}
+
+// SARIF renders findings as a SARIF 2.1.0 log that code scanning tools,
+// such as GitHub code scanning, can ingest.
+func SARIF(findings []Finding, toolVersion string) ([]byte, error) {
+	type location struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region struct {
+				StartLine int `json:"startLine"`
+			} `json:"region"`
+		} `json:"physicalLocation"`
+	}
+	type result struct {
+		RuleID  string `json:"ruleId"`
+		Level   string `json:"level"`
+		Message struct {
+			Text string `json:"text"`
+		} `json:"message"`
+		Locations []location `json:"locations"`
+	}
+
+	results := make([]result, 0, len(findings))
+	for _, f := range findings {
+		var r result
+		r.RuleID = "gitter-review"
+		r.Level = sarifLevels[f.Severity]
+		r.Message.Text = f.Message
+		var loc location
+		loc.PhysicalLocation.ArtifactLocation.URI = f.File
+		loc.PhysicalLocation.Region.StartLine = f.Line
+		r.Locations = []location{loc}
+		results = append(results, r)
+	}
+
+	log := map[string]interface{}{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []interface{}{
+			map[string]interface{}{
+				"tool": map[string]interface{}{
+					"driver": map[string]interface{}{
+						"name":           "gitter",
+						"version":        toolVersion,
+						"informationUri": "https://github.com/biswajitpain/gitter",
+						"rules": []interface{}{
+							map[string]interface{}{
+								"id":               "gitter-review",
+								"name":             "LLMReview",
+								"shortDescription": map[string]string{"text": "LLM-assisted code review finding"},
+							},
+						},
+					},
+				},
+				"results": results,
+			},
+		},
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
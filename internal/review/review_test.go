@@ -0,0 +1,134 @@
+package review_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/biswajitpain/gitter/internal/diff"
+	"github.com/biswajitpain/gitter/internal/review"
+)
+
+const sampleDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -10,3 +10,4 @@ func main() {
+ 	a := 1
+-	b := 2
++	b := 3
++	c := a / b
+ 	fmt.Println(a, b)
+`
+
+func parseSample(t *testing.T) []*diff.File {
+	t.Helper()
+	files, err := diff.Parse(sampleDiff)
+	if err != nil {
+		t.Fatalf("diff.Parse() error: %v", err)
+	}
+	return files
+}
+
+func TestFormatDiff(t *testing.T) {
+	want := "File: main.go (modified)\n" +
+		"@@ func main() {\n" +
+		"    10   \ta := 1\n" +
+		"       - \tb := 2\n" +
+		"    11 + \tb := 3\n" +
+		"    12 + \tc := a / b\n" +
+		"    13   \tfmt.Println(a, b)\n\n"
+	if got := review.FormatDiff(parseSample(t)); got != want {
+		t.Errorf("FormatDiff() =\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	files := parseSample(t)
+
+	reply := "```json\n" + `{"findings": [
+		{"file": "main.go", "line": 12, "severity": "Warning", "message": "division by b may panic if b is zero"},
+		{"file": "main.go", "line": 11, "severity": "info", "message": "magic number"}
+	]}` + "\n```"
+	findings, err := review.Parse(reply, files)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(findings) != 2 || findings[0].Line != 11 || findings[1].Severity != "warning" {
+		t.Errorf("Parse() = %+v, want two findings sorted by line with normalised severity", findings)
+	}
+
+	invalid := map[string]string{
+		"not json":        "Looks good to me!",
+		"unknown file":    `{"findings": [{"file": "other.go", "line": 1, "severity": "error", "message": "x"}]}`,
+		"bad line":        `{"findings": [{"file": "main.go", "line": 0, "severity": "error", "message": "x"}]}`,
+		"bad severity":    `{"findings": [{"file": "main.go", "line": 1, "severity": "critical", "message": "x"}]}`,
+		"empty message":   `{"findings": [{"file": "main.go", "line": 1, "severity": "error", "message": " "}]}`,
+		"unknown field":   `{"findings": [{"file": "main.go", "line": 1, "severity": "error", "message": "x", "fix": "y"}]}`,
+		"line not number": `{"findings": [{"file": "main.go", "line": "12", "severity": "error", "message": "x"}]}`,
+	}
+	for name, reply := range invalid {
+		if _, err := review.Parse(reply, files); err == nil {
+			t.Errorf("Parse(%s) should fail", name)
+		}
+	}
+
+	if findings, err := review.Parse(`{"findings": []}`, files); err != nil || len(findings) != 0 {
+		t.Errorf("Parse(no findings) = %v, %v, want an empty result", findings, err)
+	}
+}
+
+func TestText(t *testing.T) {
+	findings := []review.Finding{
+		{File: "a.go", Line: 3, Severity: "error", Message: "nil dereference"},
+		{File: "a.go", Line: 9, Severity: "info", Message: "could be simpler"},
+		{File: "b.go", Line: 1, Severity: "warning", Message: "unchecked error"},
+	}
+	want := "a.go\n  a.go:3: error: nil dereference\n  a.go:9: info: could be simpler\n\nb.go\n  b.go:1: warning: unchecked error\n"
+	if got := review.Text(findings); got != want {
+		t.Errorf("Text() =\n%s\nwant:\n%s", got, want)
+	}
+	if got := review.Text(nil); got != "No findings.\n" {
+		t.Errorf("Text(nil) = %q", got)
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	data, err := review.SARIF([]review.Finding{{File: "main.go", Line: 12, Severity: "info", Message: "magic number"}}, "1.0.0")
+	if err != nil {
+		t.Fatalf("SARIF() error: %v", err)
+	}
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("SARIF() produced invalid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 {
+		t.Fatalf("unexpected SARIF structure:\n%s", data)
+	}
+	result := log.Runs[0].Results[0]
+	location := result.Locations[0].PhysicalLocation
+	if result.Level != "note" || location.ArtifactLocation.URI != "main.go" || location.Region.StartLine != 12 {
+		t.Errorf("unexpected SARIF result:\n%s", data)
+	}
+	if !strings.Contains(string(data), `"name": "gitter"`) {
+		t.Errorf("SARIF should name the tool:\n%s", data)
+	}
+}
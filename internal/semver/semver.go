@@ -0,0 +1,196 @@
+// Package semver parses, compares and increments semantic versions
+// (https://semver.org) as used in git tags such as "v1.2.3-rc.1".
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version. Build metadata is not supported.
+type Version struct {
+	// Prefix is kept from the tag, usually "v" or empty.
+	Prefix string
+	Major  int
+	Minor  int
+	Patch  int
+	// Pre is the pre-release part without the leading "-", e.g. "rc.1".
+	Pre string
+}
+
+var versionPattern = regexp.MustCompile(`^(v?)(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
+// Parse parses a version such as "1.2.3", "v1.2.3" or "v2.0.0-beta.2".
+func Parse(s string) (Version, error) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("%q is not a semantic version", s)
+	}
+	major, _ := strconv.Atoi(m[2])
+	minor, _ := strconv.Atoi(m[3])
+	patch, _ := strconv.Atoi(m[4])
+	return Version{Prefix: m[1], Major: major, Minor: minor, Patch: patch, Pre: m[5]}, nil
+}
+
+// String formats the version with its prefix.
+func (v Version) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// IsPrerelease reports whether v has a pre-release part.
+func (v Version) IsPrerelease() bool {
+	return v.Pre != ""
+}
+
+// Release returns v without its pre-release part.
+func (v Version) Release() Version {
+	v.Pre = ""
+	return v
+}
+
+// Compare returns -1, 0 or 1 as a is lower than, equal to or higher than b
+// by semver precedence. Prefixes are ignored.
+func Compare(a, b Version) int {
+	for _, d := range []int{a.Major - b.Major, a.Minor - b.Minor, a.Patch - b.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case a.Pre == b.Pre:
+		return 0
+	case a.Pre == "":
+		return 1
+	case b.Pre == "":
+		return -1
+	}
+
+	ap, bp := strings.Split(a.Pre, "."), strings.Split(b.Pre, ".")
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		an, aErr := strconv.Atoi(ap[i])
+		bn, bErr := strconv.Atoi(bp[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return sign(an - bn)
+			}
+		case aErr == nil:
+			return -1 // numeric identifiers sort before alphanumeric ones
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(ap[i], bp[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(ap) - len(bp))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// Bump is the part of a version a release increments.
+type Bump int
+
+const (
+	None Bump = iota
+	Patch
+	Minor
+	Major
+)
+
+// String returns the lower-case name of the bump.
+func (b Bump) String() string {
+	switch b {
+	case Patch:
+		return "patch"
+	case Minor:
+		return "minor"
+	case Major:
+		return "major"
+	}
+	return "none"
+}
+
+// ParseBump parses "major", "minor" or "patch".
+func ParseBump(s string) (Bump, error) {
+	for _, b := range []Bump{Patch, Minor, Major} {
+		if s == b.String() {
+			return b, nil
+		}
+	}
+	return None, fmt.Errorf("unknown bump %q, use major, minor or patch", s)
+}
+
+// Increment returns the release that follows v for the given bump. The
+// pre-release part is dropped; None returns v unchanged.
+func (v Version) Increment(b Bump) Version {
+	v.Pre = ""
+	switch b {
+	case Major:
+		return Version{Prefix: v.Prefix, Major: v.Major + 1}
+	case Minor:
+		return Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor + 1}
+	case Patch:
+		v.Patch++
+	}
+	return v
+}
+
+// NextPrerelease returns the next pre-release of target with the given
+// identifier, e.g. "rc", numbering after any of existing that are already
+// pre-releases of target with that identifier: v1.3.0-rc.1, v1.3.0-rc.2...
+func NextPrerelease(target Version, id string, existing []Version) Version {
+	n := 0
+	for _, v := range existing {
+		if v.Major != target.Major || v.Minor != target.Minor || v.Patch != target.Patch {
+			continue
+		}
+		rest, ok := strings.CutPrefix(v.Pre, id+".")
+		if !ok {
+			continue
+		}
+		if k, err := strconv.Atoi(rest); err == nil && k > n {
+			n = k
+		}
+	}
+	target.Pre = fmt.Sprintf("%s.%d", id, n+1)
+	return target
+}
+
+// Tagged is a version together with the tag it was parsed from.
+type Tagged struct {
+	Tag     string
+	Version Version
+}
+
+// FromTags returns the tags that are semantic versions, highest first.
+// Other tags are ignored.
+func FromTags(tags []string) []Tagged {
+	var versions []Tagged
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if v, err := Parse(tag); err == nil {
+			versions = append(versions, Tagged{Tag: tag, Version: v})
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return Compare(versions[i].Version, versions[j].Version) > 0
+	})
+	return versions
+}
@@ -0,0 +1,94 @@
+package semver_test
+
+import (
+	"testing"
+
+	"github.com/biswajitpain/gitter/internal/semver"
+)
+
+func mustParse(t *testing.T, s string) semver.Version {
+	t.Helper()
+	v, err := semver.Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", s, err)
+	}
+	return v
+}
+
+func TestParse(t *testing.T) {
+	for _, s := range []string{"1.2.3", "v0.1.0", "v2.0.0-rc.1", "v1.0.0-alpha-2.x"} {
+		if got := mustParse(t, s).String(); got != s {
+			t.Errorf("Parse(%q).String() = %q", s, got)
+		}
+	}
+	for _, s := range []string{"v1.2", "1.02.3", "release-1.0.0", "v1.2.3+build", "v1.2.3-"} {
+		if _, err := semver.Parse(s); err == nil {
+			t.Errorf("Parse(%q) should fail", s)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Each version is lower than the next, from the semver.org example.
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "v1.0.1", "1.1.0", "2.0.0",
+	}
+	for i := 0; i+1 < len(ordered); i++ {
+		a, b := mustParse(t, ordered[i]), mustParse(t, ordered[i+1])
+		if semver.Compare(a, b) != -1 || semver.Compare(b, a) != 1 {
+			t.Errorf("expected %s < %s", ordered[i], ordered[i+1])
+		}
+	}
+	if semver.Compare(mustParse(t, "v1.2.3"), mustParse(t, "1.2.3")) != 0 {
+		t.Errorf("prefix should not affect precedence")
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	tests := []struct {
+		from string
+		bump semver.Bump
+		want string
+	}{
+		{"v1.2.3", semver.Major, "v2.0.0"},
+		{"v1.2.3", semver.Minor, "v1.3.0"},
+		{"v1.2.3", semver.Patch, "v1.2.4"},
+		{"1.2.3", semver.None, "1.2.3"},
+		{"v1.3.0-rc.2", semver.Patch, "v1.3.1"},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.from).Increment(tt.bump).String(); got != tt.want {
+			t.Errorf("%s.Increment(%s) = %s, want %s", tt.from, tt.bump, got, tt.want)
+		}
+	}
+}
+
+func TestNextPrerelease(t *testing.T) {
+	existing := []semver.Version{
+		mustParse(t, "v1.3.0-rc.1"),
+		mustParse(t, "v1.3.0-rc.2"),
+		mustParse(t, "v1.3.0-beta.5"),
+		mustParse(t, "v1.2.0-rc.7"),
+	}
+	target := mustParse(t, "v1.3.0")
+	if got := semver.NextPrerelease(target, "rc", existing).String(); got != "v1.3.0-rc.3" {
+		t.Errorf("NextPrerelease(rc) = %s, want v1.3.0-rc.3", got)
+	}
+	if got := semver.NextPrerelease(target, "alpha", existing).String(); got != "v1.3.0-alpha.1" {
+		t.Errorf("NextPrerelease(alpha) = %s, want v1.3.0-alpha.1", got)
+	}
+}
+
+func TestFromTags(t *testing.T) {
+	got := semver.FromTags([]string{"v1.0.0", "nightly", "v1.10.0", "v1.2.0", "v1.10.0-rc.1"})
+	want := []string{"v1.10.0", "v1.10.0-rc.1", "v1.2.0", "v1.0.0"}
+	if len(got) != len(want) {
+		t.Fatalf("FromTags() returned %d tags, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Tag != want[i] {
+			t.Errorf("FromTags()[%d] = %s, want %s", i, got[i].Tag, want[i])
+		}
+	}
+}
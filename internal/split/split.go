@@ -0,0 +1,250 @@
+// Package split divides a staged change into groups of hunks that can be
+// committed separately, by directory, by kind of file, or as clustered by
+// an LLM.
+package split
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/biswajitpain/gitter/internal/diff"
+)
+
+// Part is some or all of the hunks of one file.
+type Part struct {
+	File  *diff.File
+	Hunks []*diff.Hunk
+}
+
+// Patch renders the part as a patch for "git apply".
+func (p Part) Patch() string {
+	return p.File.Patch(p.Hunks)
+}
+
+// Group is a proposed commit.
+type Group struct {
+	// Name describes the group, e.g. a directory or "tests".
+	Name  string
+	Parts []Part
+}
+
+// Patch renders every part of the group as one patch.
+func (g *Group) Patch() string {
+	var b strings.Builder
+	for _, part := range g.Parts {
+		b.WriteString(part.Patch())
+	}
+	return b.String()
+}
+
+// Files returns the group's parts as files holding only the group's hunks,
+// for generating commit messages and stats.
+func (g *Group) Files() []*diff.File {
+	files := make([]*diff.File, len(g.Parts))
+	for i, part := range g.Parts {
+		file := *part.File
+		file.Hunks = part.Hunks
+		files[i] = &file
+	}
+	return files
+}
+
+// add adds part to the group, merging it with an existing part of the same
+// file so that each file appears once, with its hunks in diff order.
+func (g *Group) add(part Part) {
+	for i := range g.Parts {
+		if g.Parts[i].File != part.File {
+			continue
+		}
+		hunks := append(append([]*diff.Hunk{}, g.Parts[i].Hunks...), part.Hunks...)
+		order := map[*diff.Hunk]int{}
+		for j, h := range part.File.Hunks {
+			order[h] = j
+		}
+		sort.SliceStable(hunks, func(a, b int) bool { return order[hunks[a]] < order[hunks[b]] })
+		g.Parts[i].Hunks = hunks
+		return
+	}
+	g.Parts = append(g.Parts, part)
+}
+
+// Units splits files into the smallest parts that can be committed on
+// their own: one per hunk for modified text files, and the whole file for
+// added, deleted, renamed, copied or binary files and mode changes, which
+// git can only apply in one piece.
+func Units(files []*diff.File) []Part {
+	var units []Part
+	for _, file := range files {
+		if file.Change != diff.Modified || file.Binary || file.ModeChanged() || len(file.Hunks) < 2 {
+			units = append(units, Part{File: file, Hunks: file.Hunks})
+			continue
+		}
+		for _, h := range file.Hunks {
+			units = append(units, Part{File: file, Hunks: []*diff.Hunk{h}})
+		}
+	}
+	return units
+}
+
+// By groups whole files by the name key returns for their path. Groups are
+// sorted by name.
+func By(files []*diff.File, key func(path string) string) []*Group {
+	groups := map[string]*Group{}
+	for _, file := range files {
+		name := key(file.Path())
+		if groups[name] == nil {
+			groups[name] = &Group{Name: name}
+		}
+		groups[name].add(Part{File: file, Hunks: file.Hunks})
+	}
+
+	result := make([]*Group, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, g)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Directory is a key for By that groups files by their directory.
+func Directory(p string) string {
+	dir := path.Dir(p)
+	if dir == "." {
+		return "(root)"
+	}
+	return dir
+}
+
+// Kind is a key for By that groups files by what they are: tests, docs,
+// ci, build, or otherwise their extension.
+func Kind(p string) string {
+	base := path.Base(p)
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	dirs := "/" + path.Dir(p) + "/"
+
+	switch {
+	case strings.HasSuffix(stem, "_test") || strings.HasPrefix(stem, "test_") ||
+		strings.HasSuffix(stem, ".test") || strings.HasSuffix(stem, ".spec") ||
+		strings.Contains(dirs, "/test/") || strings.Contains(dirs, "/tests/") || strings.Contains(dirs, "/testdata/"):
+		return "tests"
+	case strings.Contains(dirs, "/.github/") || strings.Contains(dirs, "/.circleci/") || base == ".gitlab-ci.yml" || base == ".travis.yml":
+		return "ci"
+	case ext == ".md" || ext == ".rst" || ext == ".adoc" || strings.Contains(dirs, "/docs/") || strings.EqualFold(stem, "LICENSE"):
+		return "docs"
+	case isBuildFile(base):
+		return "build"
+	case ext == "":
+		return "other"
+	}
+	return strings.TrimPrefix(ext, ".")
+}
+
+// isBuildFile reports whether base is a build, packaging or dependency file.
+func isBuildFile(base string) bool {
+	switch base {
+	case "go.mod", "go.sum", "go.work", "go.work.sum", "Makefile", "Dockerfile", ".goreleaser.yml", ".goreleaser.yaml",
+		"package.json", "package-lock.json", "yarn.lock", "pnpm-lock.yaml", "Cargo.toml", "Cargo.lock",
+		"pyproject.toml", "requirements.txt", "poetry.lock", "Gemfile", "Gemfile.lock", "pom.xml", "build.gradle":
+		return true
+	}
+	return strings.HasPrefix(base, "Dockerfile.")
+}
+
+// DescribeUnits renders units for an LLM prompt, numbered from 1. Each
+// unit shows at most maxLines changed lines. Units of files for which
+// allowed returns false are listed without their changes; a nil allowed
+// shows every unit.
+func DescribeUnits(units []Part, maxLines int, allowed func(*diff.File) bool) string {
+	var b strings.Builder
+	for i, unit := range units {
+		fmt.Fprintf(&b, "### Hunk %d: %s (%s)\n", i+1, unit.File.Path(), unit.File.Change)
+		if allowed != nil && !allowed(unit.File) {
+			b.WriteString("(changes withheld)\n\n")
+			continue
+		}
+		shown := 0
+		for _, h := range unit.Hunks {
+			for _, line := range h.Lines {
+				if line.Kind != diff.Addition && line.Kind != diff.Deletion {
+					continue
+				}
+				if shown == maxLines {
+					b.WriteString("...\n")
+					break
+				}
+				marker := "+"
+				if line.Kind == diff.Deletion {
+					marker = "-"
+				}
+				b.WriteString(marker + line.Content + "\n")
+				shown++
+			}
+		}
+		if unit.File.Binary {
+			b.WriteString("(binary file)\n")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// clusterReply is the JSON document an LLM is asked to reply with.
+type clusterReply struct {
+	Groups []struct {
+		Name  string `json:"name"`
+		Hunks []int  `json:"hunks"`
+	} `json:"groups"`
+}
+
+// FromClusters builds groups from an LLM reply assigning the numbered units
+// (as rendered by DescribeUnits) to named groups. Units the reply leaves
+// out are collected in a final "other changes" group; a unit assigned more
+// than once stays in its first group.
+func FromClusters(units []Part, reply string) ([]*Group, error) {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("the reply does not contain a JSON object")
+	}
+	var parsed clusterReply
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("the reply is not valid clustering JSON: %w", err)
+	}
+
+	assigned := make([]bool, len(units))
+	var groups []*Group
+	for _, cluster := range parsed.Groups {
+		g := &Group{Name: strings.TrimSpace(cluster.Name)}
+		if g.Name == "" {
+			g.Name = fmt.Sprintf("group %d", len(groups)+1)
+		}
+		for _, n := range cluster.Hunks {
+			if n < 1 || n > len(units) || assigned[n-1] {
+				continue
+			}
+			assigned[n-1] = true
+			g.add(units[n-1])
+		}
+		if len(g.Parts) > 0 {
+			groups = append(groups, g)
+		}
+	}
+
+	rest := &Group{Name: "other changes"}
+	for i, unit := range units {
+		if !assigned[i] {
+			rest.add(unit)
+		}
+	}
+	if len(rest.Parts) > 0 {
+		groups = append(groups, rest)
+	}
+	if len(groups) == 0 {
+		return nil, errors.New("the reply does not assign any hunks")
+	}
+	return groups, nil
+}
@@ -0,0 +1,147 @@
+package split_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/biswajitpain/gitter/internal/diff"
+	"github.com/biswajitpain/gitter/internal/split"
+)
+
+const stagedDiff = `diff --git a/cmd/cr.go b/cmd/cr.go
+index 1111111..2222222 100644
+--- a/cmd/cr.go
++++ b/cmd/cr.go
+@@ -1,3 +1,3 @@
+ package cmd
+-var a = 1
++var a = 2
+ 
+@@ -20,3 +20,3 @@ func x() {
+ 	one()
+-	two()
++	three()
+ 	four()
+diff --git a/cmd/cr_test.go b/cmd/cr_test.go
+index 3333333..4444444 100644
+--- a/cmd/cr_test.go
++++ b/cmd/cr_test.go
+@@ -5,2 +5,3 @@
+ func TestA(t *testing.T) {
++	t.Parallel()
+ }
+diff --git a/README.md b/README.md
+new file mode 100644
+index 0000000..5555555
+--- /dev/null
++++ b/README.md
+@@ -0,0 +1,2 @@
++# Title
++text
+`
+
+func parse(t *testing.T) []*diff.File {
+	t.Helper()
+	files, err := diff.Parse(stagedDiff)
+	if err != nil {
+		t.Fatalf("diff.Parse() error: %v", err)
+	}
+	return files
+}
+
+func names(groups []*split.Group) string {
+	var names []string
+	for _, g := range groups {
+		var paths []string
+		for _, part := range g.Parts {
+			paths = append(paths, part.File.Path())
+		}
+		names = append(names, g.Name+"="+strings.Join(paths, ","))
+	}
+	return strings.Join(names, " ")
+}
+
+func TestBy(t *testing.T) {
+	files := parse(t)
+
+	if got, want := names(split.By(files, split.Directory)), "(root)=README.md cmd=cmd/cr.go,cmd/cr_test.go"; got != want {
+		t.Errorf("By(Directory) = %s, want %s", got, want)
+	}
+	if got, want := names(split.By(files, split.Kind)), "docs=README.md go=cmd/cr.go tests=cmd/cr_test.go"; got != want {
+		t.Errorf("By(Kind) = %s, want %s", got, want)
+	}
+}
+
+func TestKind(t *testing.T) {
+	tests := map[string]string{
+		"internal/x/x_test.go":      "tests",
+		"web/src/app.spec.ts":       "tests",
+		"tests/test_api.py":         "tests",
+		".github/workflows/ci.yml":  "ci",
+		"docs/setup.txt":            "docs",
+		"CHANGELOG.md":              "docs",
+		"go.mod":                    "build",
+		"deploy/Dockerfile.prod":    "build",
+		"internal/config/config.go": "go",
+		"scripts/release":           "other",
+	}
+	for p, want := range tests {
+		if got := split.Kind(p); got != want {
+			t.Errorf("Kind(%q) = %q, want %q", p, got, want)
+		}
+	}
+}
+
+func TestUnits(t *testing.T) {
+	units := split.Units(parse(t))
+	// cr.go has two hunks that can be committed separately; the other files
+	// are one unit each.
+	if len(units) != 4 {
+		t.Fatalf("Units() returned %d units, want 4", len(units))
+	}
+	if units[0].File.Path() != "cmd/cr.go" || units[1].File.Path() != "cmd/cr.go" || len(units[0].Hunks) != 1 {
+		t.Errorf("cr.go should be split into one unit per hunk")
+	}
+	if !strings.Contains(split.DescribeUnits(units, 10, nil), "### Hunk 4: README.md (added)\n+# Title\n+text\n") {
+		t.Errorf("DescribeUnits() should number units and show their changes:\n%s", split.DescribeUnits(units, 10, nil))
+	}
+
+	hidden := split.DescribeUnits(units, 10, func(f *diff.File) bool { return f.Path() != "README.md" })
+	if !strings.Contains(hidden, "### Hunk 4: README.md (added)\n(changes withheld)\n") || strings.Contains(hidden, "+# Title") {
+		t.Errorf("DescribeUnits() should list withheld units without their changes:\n%s", hidden)
+	}
+}
+
+func TestFromClusters(t *testing.T) {
+	files := parse(t)
+	units := split.Units(files)
+
+	reply := "```json\n" + `{"groups": [
+		{"name": "rename variable", "hunks": [3, 1]},
+		{"name": "docs", "hunks": [4, 1, 99]}
+	]}` + "\n```"
+	groups, err := split.FromClusters(units, reply)
+	if err != nil {
+		t.Fatalf("FromClusters() error: %v", err)
+	}
+	if got, want := names(groups), "rename variable=cmd/cr_test.go,cmd/cr.go docs=README.md other changes=cmd/cr.go"; got != want {
+		t.Errorf("FromClusters() = %s, want %s", got, want)
+	}
+
+	// The two hunks of cr.go end up in different groups, each with the
+	// file header so both patches apply.
+	first, rest := groups[0].Patch(), groups[2].Patch()
+	if !strings.Contains(first, "+var a = 2") || strings.Contains(first, "+\tthree()") {
+		t.Errorf("first group should hold only the first cr.go hunk:\n%s", first)
+	}
+	if !strings.HasPrefix(rest, "diff --git a/cmd/cr.go b/cmd/cr.go\n") || !strings.Contains(rest, "+\tthree()") {
+		t.Errorf("remaining group should hold the second cr.go hunk with its header:\n%s", rest)
+	}
+	if files := groups[2].Files(); len(files) != 1 || files[0].Added() != 1 {
+		t.Errorf("Files() should only count the group's hunks")
+	}
+
+	if _, err := split.FromClusters(units, "I would split this into two commits."); err == nil {
+		t.Errorf("FromClusters() should fail without JSON")
+	}
+}
@@ -1,7 +1,11 @@
 package main
 
-import "github.com/biswajitpain/gitter/cmd"
+import (
+	"os"
+
+	"github.com/biswajitpain/gitter/cmd"
+)
 
 func main() {
-	cmd.Execute()
+	os.Exit(cmd.Execute())
 }